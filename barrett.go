@@ -0,0 +1,68 @@
+package ctrsa
+
+// barrettModulus is a modulus prepared for Barrett reduction
+//
+// Compared to mod, which shifts in one limb at a time, this allows reducing
+// numbers with up to twice as many limbs as the modulus with just two multiplications.
+type barrettModulus struct {
+	m *modulus
+	// mu is floor(b^2n / m), with b = 2^_W, and n the number of limbs in m.
+	//
+	// This has an announced length of n + 1 limbs.
+	mu *nat
+}
+
+// newBarrettModulus precomputes the constants needed for Barrett reduction modulo m
+//
+// This uses bit by bit long division, which is slow, but doesn't leak the value of m.
+func newBarrettModulus(m *modulus) *barrettModulus {
+	n := len(m.nat.limbs)
+	mExpanded := m.nat.clone().expand(n + 1)
+	// The remainder stays below 2m, so n + 1 limbs suffice
+	r := new(nat).expand(n + 1)
+	// We divide b^2n, which has 2n * _W + 1 bits
+	top := uint(2 * n * _W)
+	mu := new(nat).expand(2*n + 1)
+	for i := int(top); i >= 0; i-- {
+		r.add(1, r)
+		if uint(i) == top {
+			r.limbs[0] |= 1
+		}
+		geq := r.cmpGeq(mExpanded)
+		r.sub(geq, mExpanded)
+		mu.limbs[i/_W] |= uint(geq) << (uint(i) % _W)
+	}
+	// Because m has n limbs, we know that mu < b^(n + 1)
+	mu.limbs = mu.limbs[:n+1]
+	return &barrettModulus{m: m, mu: mu}
+}
+
+// barrettReduce calculates out = x mod m, using Barrett reduction
+//
+// x can have at most twice as many limbs as the modulus.
+//
+// The output will be expanded and overwritten to have the correct size.
+func (out *nat) barrettReduce(x *nat, bm *barrettModulus) *nat {
+	// See Algorithm 14.42 of the Handbook of Applied Cryptography
+	m := bm.m.nat
+	n := len(m.limbs)
+	x = x.clone().expand(2 * n)
+
+	// q3 = floor(floor(x / b^(n - 1)) * mu / b^(n + 1))
+	q1 := &nat{x.limbs[n-1:]}
+	q2 := new(nat).mul(q1, bm.mu)
+	q3 := &nat{q2.limbs[n+1:]}
+
+	// r = (x - q3 * m) mod b^(n + 1), which ends up being less than 3m
+	r := x.clone().expand(n + 1)
+	r.subCarry(new(nat).mulLow(q3, m, n+1))
+
+	mExpanded := m.clone().expand(n + 1)
+	for i := 0; i < 2; i++ {
+		r.sub(r.cmpGeq(mExpanded), mExpanded)
+	}
+
+	out.expand(n)
+	copy(out.limbs, r.limbs)
+	return out
+}
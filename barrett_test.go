@@ -0,0 +1,78 @@
+package ctrsa
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestBarrettMu(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 20; i++ {
+		mNat := (*nat)(nil).Generate(r, 1+r.Intn(8)).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[len(mNat.limbs)-1] |= 1
+		m := modulusFromNat(mNat)
+		bm := newBarrettModulus(m)
+
+		n := len(m.nat.limbs)
+		expected := new(big.Int).Lsh(big.NewInt(1), uint(2*n*_W))
+		expected.Div(expected, m.nat.toBig())
+		if bm.mu.toBig().Cmp(expected) != 0 {
+			t.Errorf("mu for %+v: %+v != %v", m.nat, bm.mu, expected)
+		}
+	}
+}
+
+func TestBarrettReduce(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 200; i++ {
+		mNat := (*nat)(nil).Generate(r, 1+r.Intn(8)).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[len(mNat.limbs)-1] |= 1
+		m := modulusFromNat(mNat)
+		bm := newBarrettModulus(m)
+
+		x := (*nat)(nil).Generate(r, 1+r.Intn(2*len(m.nat.limbs))).Interface().(*nat)
+		expected := new(big.Int).Mod(x.toBig(), m.nat.toBig())
+		actual := new(nat).barrettReduce(x, bm)
+		if len(actual.limbs) != len(m.nat.limbs) {
+			t.Errorf("%+v doesn't have the size of %+v", actual, m.nat)
+		}
+		if actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("%+v mod %+v: %+v != %v", x, m.nat, actual, expected)
+		}
+		// Make sure that we agree with the existing reduction
+		if actual.cmpEq(new(nat).mod(x, m)) != 1 {
+			t.Errorf("%+v mod %+v: barrettReduce and mod disagree", x, m.nat)
+		}
+	}
+}
+
+func makeBenchmarkDoubleValue() *nat {
+	x := make([]uint, 64)
+	for i := 0; i < 64; i++ {
+		x[i] = _MASK - 1
+	}
+	return &nat{limbs: x}
+}
+
+func BenchmarkModDoubleWidth(b *testing.B) {
+	x := makeBenchmarkDoubleValue()
+	m := makeBenchmarkModulus()
+	out := new(nat)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out.mod(x, m)
+	}
+}
+
+func BenchmarkBarrettReduce(b *testing.B) {
+	x := makeBenchmarkDoubleValue()
+	bm := newBarrettModulus(makeBenchmarkModulus())
+	out := new(nat)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out.barrettReduce(x, bm)
+	}
+}
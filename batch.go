@@ -0,0 +1,79 @@
+package ctrsa
+
+import (
+	"crypto"
+	"sync"
+)
+
+// BatchOption configures the behavior of VerifyBatch.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	workers int
+}
+
+// Parallel makes VerifyBatch spread the verifications over a number of goroutines.
+//
+// A number of workers below 2 means verifying everything on the calling goroutine,
+// which is also the default.
+func Parallel(workers int) BatchOption {
+	return func(opts *batchOptions) {
+		opts.workers = workers
+	}
+}
+
+// VerifyBatch verifies many RSA PKCS #1 v1.5 signatures made with this key.
+//
+// The i-th result says whether sigs[i] is a valid signature of digests[i], which
+// should be the result of hashing the message with hash, as for VerifyPKCS1v15.
+//
+// The montgomery constants for the modulus are only computed once, and then shared
+// between all of the verifications. This state is only ever read, which makes it
+// safe to share with the goroutines started by the Parallel option.
+//
+// If sigs and digests don't have the same length, which signature goes with which
+// digest is ambiguous, so every signature is reported as invalid.
+func (pub *PublicKey) VerifyBatch(hash crypto.Hash, sigs [][]byte, digests [][]byte, opts ...BatchOption) []bool {
+	var options batchOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	results := make([]bool, len(sigs))
+	if len(sigs) != len(digests) || checkPub(pub) != nil {
+		return results
+	}
+	nModulus := modulusFromNat(natFromBig(pub.N))
+	k := pub.Size()
+
+	verify := func(i int) {
+		hashLen, prefix, err := pkcs1v15HashInfo(hash, len(digests[i]))
+		if err != nil || len(sigs[i]) != k {
+			return
+		}
+		expected, err := emsaPKCS1v15Encode(hashLen, prefix, digests[i], k)
+		if err != nil {
+			return
+		}
+		results[i] = verifyPKCS1v15(pub, nModulus, expected, sigs[i]) == nil
+	}
+
+	if options.workers < 2 {
+		for i := range sigs {
+			verify(i)
+		}
+		return results
+	}
+	// Each worker handles every workers-th item, so no two of them write to the same result
+	var wg sync.WaitGroup
+	for w := 0; w < options.workers && w < len(sigs); w++ {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+			for i := start; i < len(sigs); i += options.workers {
+				verify(i)
+			}
+		}(w)
+	}
+	wg.Wait()
+	return results
+}
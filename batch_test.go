@@ -0,0 +1,84 @@
+package ctrsa
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func makeBatch(t testing.TB, n int) (sigs [][]byte, digests [][]byte) {
+	for i := 0; i < n; i++ {
+		digest := sha256.Sum256([]byte(fmt.Sprintf("message %d", i)))
+		sig, err := SignPKCS1v15(nil, rsaPrivateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs = append(sigs, sig)
+		digests = append(digests, digest[:])
+	}
+	return sigs, digests
+}
+
+func TestVerifyBatch(t *testing.T) {
+	sigs, digests := makeBatch(t, 8)
+	// Corrupt some of the signatures, in different ways
+	sigs[1] = append([]byte{}, sigs[1]...)
+	sigs[1][0] ^= 1
+	sigs[4] = sigs[4][1:]
+	digests[6] = digests[5]
+	expected := []bool{true, false, true, true, false, true, false, true}
+
+	pub := &rsaPrivateKey.PublicKey
+	for _, workers := range []int{0, 1, 3, 8, 20} {
+		results := pub.VerifyBatch(crypto.SHA256, sigs, digests, Parallel(workers))
+		for i := range expected {
+			if results[i] != expected[i] {
+				t.Errorf("%d workers: result %d: %v != %v", workers, i, results[i], expected[i])
+			}
+			if single := pub.VerifyPKCS1v15(crypto.SHA256, digests[i], sigs[i]) == nil; single != results[i] {
+				t.Errorf("%d workers: result %d doesn't match VerifyPKCS1v15", workers, i)
+			}
+		}
+	}
+}
+
+func TestVerifyBatchMismatchedLengths(t *testing.T) {
+	sigs, digests := makeBatch(t, 4)
+	pub := &rsaPrivateKey.PublicKey
+	for _, workers := range []int{0, 3} {
+		for _, digests := range [][][]byte{digests[:3], append(digests, digests[0])} {
+			results := pub.VerifyBatch(crypto.SHA256, sigs, digests, Parallel(workers))
+			if len(results) != len(sigs) {
+				t.Fatalf("%d results for %d signatures", len(results), len(sigs))
+			}
+			for i, ok := range results {
+				if ok {
+					t.Errorf("%d workers, %d digests: signature %d accepted", workers, len(digests), i)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	sigs, digests := makeBatch(b, 64)
+	pub := &rsaPrivateKey.PublicKey
+
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range sigs {
+				if err := pub.VerifyPKCS1v15(crypto.SHA256, digests[j], sigs[j]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("batch/%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pub.VerifyBatch(crypto.SHA256, sigs, digests, Parallel(workers))
+			}
+		})
+	}
+}
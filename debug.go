@@ -0,0 +1,37 @@
+package ctrsa
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// assertReduced panics if x doesn't have the announced length of m, or isn't reduced modulo m
+//
+// Modular operations silently produce garbage when this isn't the case. The check only
+// happens in builds with the ctrsa_debug tag, and compiles away otherwise.
+func assertReduced(op string, x *nat, m *modulus) {
+	if !debugChecks {
+		return
+	}
+	if len(x.limbs) != len(m.nat.limbs) {
+		panic(fmt.Sprintf("ctrsa: %s: operand has %d limbs, but the modulus has %d", op, len(x.limbs), len(m.nat.limbs)))
+	}
+	if x.cmpGeq(m.nat) == 1 {
+		panic(fmt.Sprintf("ctrsa: %s: operand %v is not reduced modulo %v", op, x, m))
+	}
+}
+
+// debugMontgomeryMuls counts calls to montgomeryMul, in builds with the ctrsa_debug tag
+//
+// Tests use this to check that the number of multiplications done doesn't depend
+// on secret values. It stays at 0 otherwise. Since multiplications can happen on
+// several goroutines at once, it's only accessed atomically.
+var debugMontgomeryMuls int64
+
+// countMontgomeryMul increments debugMontgomeryMuls, compiling away without the ctrsa_debug tag
+func countMontgomeryMul() {
+	if !debugChecks {
+		return
+	}
+	atomic.AddInt64(&debugMontgomeryMuls, 1)
+}
@@ -0,0 +1,7 @@
+//go:build !ctrsa_debug
+// +build !ctrsa_debug
+
+package ctrsa
+
+// debugChecks enables expensive sanity checks on the inputs of modular operations
+const debugChecks = false
@@ -0,0 +1,51 @@
+//go:build ctrsa_debug
+// +build ctrsa_debug
+
+package ctrsa
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestAssertReduced(t *testing.T) {
+	m := modulusFromNat(&nat{[]uint{13, 1}})
+	examples := map[string]func(){
+		"unreduced modAdd":        func() { (&nat{[]uint{13, 1}}).modAdd(&nat{[]uint{1, 0}}, m) },
+		"unreduced modSub":        func() { (&nat{[]uint{1, 0}}).modSub(&nat{[]uint{14, 1}}, m) },
+		"short modMul":            func() { (&nat{[]uint{1}}).modMul(&nat{[]uint{1, 0}}, m) },
+		"unreduced montgomeryMul": func() { new(nat).expandFor(m).montgomeryMul(&nat{[]uint{1, 0}}, &nat{[]uint{0, 2}}, m) },
+	}
+	for name, f := range examples {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+	// Reduced operands shouldn't trigger anything
+	(&nat{[]uint{12, 1}}).modAdd(&nat{[]uint{1, 0}}, m)
+}
+
+func TestExpMultiplicationsIndependentOfExponent(t *testing.T) {
+	m := modulusFromNat(&nat{[]uint{13, 13}})
+	x := &nat{[]uint{3, 0}}
+	// Exponents of every weight, padded to the same length, take as many multiplications
+	counts := make(map[int64]int)
+	for _, e := range [][]byte{{0}, {1}, {0x80, 0}, {0x01, 0x01}, {0xFF, 0xFF}, {0xFF, 0xFF, 0xFF, 0xFF}} {
+		before := atomic.LoadInt64(&debugMontgomeryMuls)
+		new(nat).exp(x, e, m)
+		counts[atomic.LoadInt64(&debugMontgomeryMuls)-before]++
+	}
+	if len(counts) != 1 {
+		t.Errorf("multiplication counts depend on the exponent: %v", counts)
+	}
+	for count := range counts {
+		if count == 0 {
+			t.Errorf("no multiplications were counted")
+		}
+	}
+}
@@ -0,0 +1,69 @@
+//go:build ignore
+// +build ignore
+
+// This program generates montgomery_sizes.go, containing versions of the
+// montgomery multiplication loop specialized for common RSA modulus sizes.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+)
+
+// The limbs have 63 bits, so these are the limb counts for 2048, 3072, and 4096 bit moduli
+var sizes = []int{33, 49, 66}
+
+func main() {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen_montgomery.go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package ctrsa\n\nimport \"math/bits\"\n\n")
+
+	fmt.Fprintf(&buf, "// montgomeryLoopFixed returns a version of montgomeryLoopGeneric specialized for size limbs, if there is one\n")
+	fmt.Fprintf(&buf, "func montgomeryLoopFixed(size int) func(out, x, y, m []uint, m0inv uint) uint {\n")
+	fmt.Fprintf(&buf, "switch size {\n")
+	for _, n := range sizes {
+		fmt.Fprintf(&buf, "case %d:\nreturn montgomeryLoop%d\n", n, n)
+	}
+	fmt.Fprintf(&buf, "}\nreturn nil\n}\n")
+
+	for _, n := range sizes {
+		fmt.Fprintf(&buf, "\n// montgomeryLoop%d is montgomeryLoopAnySize, for exactly %d limbs, with the inner loop unrolled\n", n, n)
+		fmt.Fprintf(&buf, "func montgomeryLoop%d(out, x, y, m []uint, m0inv uint) uint {\n", n)
+		fmt.Fprintf(&buf, "out, x, y, m = out[:%d], x[:%d], y[:%d], m[:%d]\n", n, n, n, n)
+		fmt.Fprintf(&buf, "overflow := uint(0)\n")
+		fmt.Fprintf(&buf, "for i := 0; i < %d; i++ {\n", n)
+		fmt.Fprintf(&buf, "xi := x[i]\n")
+		fmt.Fprintf(&buf, "f := ((out[0] + xi*y[0]) * m0inv) & _MASK\n")
+		fmt.Fprintf(&buf, "var carry, hi, lo, zLo, zHi, c uint\n")
+		for j := 0; j < n; j++ {
+			// Same as the inner loop of montgomeryLoopAnySize
+			fmt.Fprintf(&buf, "hi, lo = bits.Mul(xi, y[%d])\n", j)
+			fmt.Fprintf(&buf, "zLo, c = bits.Add(out[%d], lo, 0)\n", j)
+			fmt.Fprintf(&buf, "zHi, _ = bits.Add(0, hi, c)\n")
+			fmt.Fprintf(&buf, "hi, lo = bits.Mul(f, m[%d])\n", j)
+			fmt.Fprintf(&buf, "zLo, c = bits.Add(zLo, lo, 0)\n")
+			fmt.Fprintf(&buf, "zHi, _ = bits.Add(zHi, hi, c)\n")
+			if j > 0 {
+				fmt.Fprintf(&buf, "zLo, c = bits.Add(zLo, carry, 0)\n")
+				fmt.Fprintf(&buf, "zHi, _ = bits.Add(zHi, 0, c)\n")
+				fmt.Fprintf(&buf, "out[%d] = zLo & _MASK\n", j-1)
+			}
+			fmt.Fprintf(&buf, "carry = (zLo >> _W) | (zHi << 1)\n")
+		}
+		fmt.Fprintf(&buf, "z := overflow + carry\n")
+		fmt.Fprintf(&buf, "out[%d] = z & _MASK\n", n-1)
+		fmt.Fprintf(&buf, "overflow = z >> _W\n")
+		fmt.Fprintf(&buf, "}\nreturn overflow\n}\n")
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("montgomery_sizes.go", out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,130 @@
+package ctrsa
+
+import "sync"
+
+// karatsubaThreshold is the number of limbs above which mul switches to Karatsuba multiplication
+//
+// Below this threshold, the overhead of recursion outweighs saving multiplications.
+// This needs to be at least 4, for the recursive step to fit its output.
+const karatsubaThreshold = 16
+
+// karatsubaPool holds scratch space for karatsubaMul, to avoid allocating on every multiplication
+var karatsubaPool = sync.Pool{
+	New: func() interface{} {
+		return new([]uint)
+	},
+}
+
+// limbsAdd calculates z += x, returning the carry
+//
+// x may be shorter than z, in which case the carry propagates all the way through z.
+func limbsAdd(z, x []uint) (c uint) {
+	for i := 0; i < len(z); i++ {
+		var xi uint
+		if i < len(x) {
+			xi = x[i]
+		}
+		res := z[i] + xi + c
+		z[i] = res & _MASK
+		c = res >> _W
+	}
+	return
+}
+
+// limbsSub calculates z -= x, returning the borrow
+//
+// x may be shorter than z, in which case the borrow propagates all the way through z.
+func limbsSub(z, x []uint) (c uint) {
+	for i := 0; i < len(z); i++ {
+		var xi uint
+		if i < len(x) {
+			xi = x[i]
+		}
+		res := z[i] - xi - c
+		z[i] = res & _MASK
+		c = res >> _W
+	}
+	return
+}
+
+// karatsubaScratchSize returns how many limbs of scratch space karatsubaMul needs for n limb inputs
+func karatsubaScratchSize(n, threshold int) int {
+	if n < threshold {
+		return 0
+	}
+	m := n - n/2
+	// Each level needs two sums of m + 1 limbs, and their product, and the
+	// deepest recursive call happens on these sums.
+	return 4*(m+1) + karatsubaScratchSize(m+1, threshold)
+}
+
+// karatsubaMul calculates out = x * y
+//
+// x and y must have the same length n, and out must have 2n limbs. scratch
+// must have at least karatsubaScratchSize(n, threshold) limbs. Inputs with
+// fewer than threshold limbs use schoolbook multiplication instead.
+//
+// Like mulLow, this only depends on the lengths of the inputs, and not their values.
+func karatsubaMul(out, x, y, scratch []uint, threshold int) {
+	n := len(x)
+	if n < threshold {
+		(&nat{out}).mulLow(&nat{x}, &nat{y}, len(out))
+		return
+	}
+	// With b^h as the split point, we have:
+	//
+	//   x * y = z2 b^2h + z1 b^h + z0
+	//
+	// where z0 = x0 y0, z2 = x1 y1, and z1 = (x0 + x1)(y0 + y1) - z0 - z2.
+	h := n / 2
+	m := n - h
+	x0, x1 := x[:h], x[h:]
+	y0, y1 := y[:h], y[h:]
+
+	karatsubaMul(out[:2*h], x0, y0, scratch, threshold)
+	karatsubaMul(out[2*h:], x1, y1, scratch, threshold)
+
+	xSum := scratch[:m+1]
+	ySum := scratch[m+1 : 2*(m+1)]
+	z1 := scratch[2*(m+1) : 4*(m+1)]
+	rest := scratch[4*(m+1):]
+	copy(xSum, x1)
+	xSum[m] = 0
+	limbsAdd(xSum, x0)
+	copy(ySum, y1)
+	ySum[m] = 0
+	limbsAdd(ySum, y0)
+
+	karatsubaMul(z1, xSum, ySum, rest, threshold)
+	limbsSub(z1, out[:2*h])
+	limbsSub(z1, out[2*h:])
+	// z1 has 2m + 2 <= 2n - h limbs, since h >= 2, so it fits
+	limbsAdd(out[h:], z1)
+}
+
+// mulKaratsuba calculates out = x * y, using Karatsuba multiplication
+//
+// x and y must have the same announced length, and the output will be
+// expanded and overwritten to have twice that many limbs.
+func (out *nat) mulKaratsuba(x, y *nat) *nat {
+	return out.mulKaratsubaThreshold(x, y, karatsubaThreshold)
+}
+
+// mulKaratsubaThreshold is mulKaratsuba, recursing down to threshold limbs instead of karatsubaThreshold
+func (out *nat) mulKaratsubaThreshold(x, y *nat, threshold int) *nat {
+	n := len(x.limbs)
+	out.expand(2 * n)
+	scratchPtr := karatsubaPool.Get().(*[]uint)
+	size := karatsubaScratchSize(n, threshold)
+	if cap(*scratchPtr) < size {
+		*scratchPtr = make([]uint, size)
+	}
+	scratch := (*scratchPtr)[:size]
+	karatsubaMul(out.limbs, x.limbs, y.limbs, scratch, threshold)
+	// The scratch space holds partial products of the inputs, which may be secret
+	for i := range scratch {
+		scratch[i] = 0
+	}
+	karatsubaPool.Put(scratchPtr)
+	return out
+}
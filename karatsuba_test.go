@@ -0,0 +1,50 @@
+package ctrsa
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestKaratsubaMatchesSchoolbook(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for n := 1; n <= 100; n++ {
+		x := (*nat)(nil).Generate(r, n).Interface().(*nat)
+		y := (*nat)(nil).Generate(r, n).Interface().(*nat)
+		// Saturated limbs make sure that all of the carries get exercised
+		if n%2 == 0 {
+			for i := range x.limbs {
+				x.limbs[i] = _MASK
+				y.limbs[i] = _MASK
+			}
+		}
+		expected := new(nat).mulLow(x, y, 2*n)
+		// A small threshold exercises a few levels of recursion
+		actual := new(nat).mulKaratsubaThreshold(x, y, 4)
+		if actual.cmpEq(expected) != 1 || len(actual.limbs) != 2*n {
+			t.Errorf("%d limbs: %+v != %+v", n, actual, expected)
+		}
+		if mul := new(nat).mul(x, y); mul.cmpEq(expected) != 1 {
+			t.Errorf("%d limbs: mul gave %+v instead of %+v", n, mul, expected)
+		}
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	for _, n := range []int{32, 48, 64, 128} {
+		r := rand.New(rand.NewSource(0))
+		x := (*nat)(nil).Generate(r, n).Interface().(*nat)
+		y := (*nat)(nil).Generate(r, n).Interface().(*nat)
+		out := new(nat)
+		b.Run(fmt.Sprintf("schoolbook/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				out.mulLow(x, y, 2*n)
+			}
+		})
+		b.Run(fmt.Sprintf("karatsuba/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				out.mulKaratsuba(x, y)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package ctrsa
+
+import "hash"
+
+// mgf1Reader streams the output of the MGF1 mask generation function, as specified in PKCS #1 v2.1
+//
+// The output is the concatenation of hash(seed || counter), for a four byte, big-endian
+// counter starting at 0. Reads never fail, and always fill the buffer.
+type mgf1Reader struct {
+	hash    hash.Hash
+	seed    []byte
+	counter [4]byte
+	// The part of the last digest that hasn't been read yet
+	buffered []byte
+	digest   []byte
+}
+
+// newMGF1Reader creates a reader for the MGF1 output of a given seed
+//
+// The seed is copied, but the hash will be used, and reset, as the output gets read.
+func newMGF1Reader(hash hash.Hash, seed []byte) *mgf1Reader {
+	return &mgf1Reader{hash: hash, seed: append([]byte(nil), seed...)}
+}
+
+func (r *mgf1Reader) Read(p []byte) (int, error) {
+	done := 0
+	for done < len(p) {
+		if len(r.buffered) == 0 {
+			r.hash.Reset()
+			r.hash.Write(r.seed)
+			r.hash.Write(r.counter[:])
+			r.digest = r.hash.Sum(r.digest[:0])
+			r.buffered = r.digest
+			incCounter(&r.counter)
+		}
+		n := copy(p[done:], r.buffered)
+		r.buffered = r.buffered[n:]
+		done += n
+	}
+	return done, nil
+}
+
+// mgf1 returns the first length bytes of the MGF1 output for a given seed
+func mgf1(hash hash.Hash, seed []byte, length int) []byte {
+	out := make([]byte, length)
+	newMGF1Reader(hash, seed).Read(out)
+	return out
+}
@@ -0,0 +1,50 @@
+package ctrsa
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+func TestMGF1(t *testing.T) {
+	examples := []struct {
+		hash     func() hash.Hash
+		seed     string
+		length   int
+		expected string
+	}{
+		{sha1.New, "foo", 3, "1ac907"},
+		{sha1.New, "foo", 5, "1ac9075cd4"},
+		{sha1.New, "bar", 5, "bc0c655e01"},
+		{sha1.New, "bar", 50, "bc0c655e016bc2931d85a2e675181adcef7f581f76df2739da74faac41627be2f7f415c89e983fd0ce80ced9878641cb4876"},
+		{sha256.New, "bar", 50, "382576a7841021cc28fc4c0948753fb8312090cea942ea4c4e735d10dc724b155f9f6069f289d61daca0cb814502ef04eae1"},
+		{sha256.New, "bar", 0, ""},
+	}
+	for _, example := range examples {
+		expected, _ := hex.DecodeString(example.expected)
+		actual := mgf1(example.hash(), []byte(example.seed), example.length)
+		if !bytes.Equal(actual, expected) {
+			t.Errorf("mgf1(%q, %d): %x != %x", example.seed, example.length, actual, expected)
+		}
+	}
+}
+
+func TestMGF1ReaderChunks(t *testing.T) {
+	expected := mgf1(sha256.New(), []byte("seed"), 200)
+	// Reads of any size should see the same stream
+	for _, chunk := range []int{1, 7, 32, 33, 64, 200} {
+		r := newMGF1Reader(sha256.New(), []byte("seed"))
+		var actual []byte
+		for len(actual) < len(expected) {
+			buf := make([]byte, chunk)
+			r.Read(buf)
+			actual = append(actual, buf...)
+		}
+		if !bytes.Equal(actual[:len(expected)], expected) {
+			t.Errorf("chunks of %d: %x != %x", chunk, actual, expected)
+		}
+	}
+}
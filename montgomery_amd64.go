@@ -0,0 +1,38 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package ctrsa
+
+// hasADX reports whether the CPU supports the MULX, ADCX, and ADOX instructions
+var hasADX = cpuHasADX()
+
+//go:noescape
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func cpuHasADX() bool {
+	maxLeaf, _, _, _ := cpuid(0, 0)
+	if maxLeaf < 7 {
+		return false
+	}
+	_, ebx, _, _ := cpuid(7, 0)
+	// BMI2 provides MULX, and ADX provides ADCX and ADOX
+	const bmi2, adx = 1 << 8, 1 << 19
+	return ebx&bmi2 != 0 && ebx&adx != 0
+}
+
+// montgomeryLoopADX is like montgomeryLoopGeneric, using MULX, ADCX, and ADOX
+//
+// The slices must not be empty, and this must only be called when hasADX is set.
+//
+//go:noescape
+func montgomeryLoopADX(out, x, y, m []uint, m0inv uint) uint
+
+// montgomeryLoop accumulates xy / R into out, returning the bit overflowing out
+//
+// out must start off as zero, and all of the slices must have the same length.
+func montgomeryLoop(out, x, y, m []uint, m0inv uint) uint {
+	if hasADX && len(out) > 0 {
+		return montgomeryLoopADX(out, x, y, m, m0inv)
+	}
+	return montgomeryLoopGeneric(out, x, y, m, m0inv)
+}
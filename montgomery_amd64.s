@@ -0,0 +1,95 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+#include "textflag.h"
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// Each step of the inner loop calculates z = out[j] + x[i] * y[j] + f * m[j] + carry,
+// over 128 bits in R9:R8. The two products are accumulated on separate carry chains,
+// through ADCX and ADOX, with R12 holding zero. Because the limbs only have 63 bits,
+// z can't overflow 128 bits, and the chains never carry out of R9.
+#define MUL_ADD_STEP(j) \
+	MOVQ  xi-8(SP), DX          \
+	MULXQ (BX)(j*8), R8, R9     \
+	MOVQ  f-16(SP), DX          \
+	MULXQ (R13)(j*8), R10, R11  \
+	XORQ  R12, R12              \
+	ADCXQ (DI)(j*8), R8         \
+	ADOXQ R10, R8               \
+	ADCXQ R12, R9               \
+	ADOXQ R11, R9               \
+	ADCXQ R14, R8               \
+	ADCXQ R12, R9
+
+// func montgomeryLoopADX(out, x, y, m []uint, m0inv uint) uint
+//
+// Register usage:
+//   DI: out, SI: x[i], BX: y, R13: m, CX: len(out), R15: remaining iterations over i
+//   AX: j, R14: carry, R8 to R12: scratch
+// The locals hold x[i], f, and the overflow bit.
+TEXT ·montgomeryLoopADX(SB), NOSPLIT, $24-112
+	MOVQ out_base+0(FP), DI
+	MOVQ out_len+8(FP), CX
+	MOVQ x_base+24(FP), SI
+	MOVQ y_base+48(FP), BX
+	MOVQ m_base+72(FP), R13
+	MOVQ CX, R15
+	MOVQ $0, overflow-24(SP)
+
+outer:
+	// f = ((out[0] + x[i] * y[0]) * m0inv) & _MASK
+	MOVQ  (SI), R8
+	MOVQ  R8, xi-8(SP)
+	IMULQ (BX), R8
+	ADDQ  (DI), R8
+	IMULQ m0inv+96(FP), R8
+	BTRQ  $63, R8
+	MOVQ  R8, f-16(SP)
+
+	// The first step only produces a carry, since its low bits are zero
+	MOVQ $0, R14
+	MOVQ $0, AX
+	MUL_ADD_STEP(AX)
+	SHRQ $63, R9, R8
+	MOVQ R8, R14
+	MOVQ $1, AX
+
+inner:
+	CMPQ AX, CX
+	JAE  innerDone
+	MUL_ADD_STEP(AX)
+	MOVQ R8, R10
+	BTRQ $63, R10
+	MOVQ R10, -8(DI)(AX*8)
+	SHRQ $63, R9, R8
+	MOVQ R8, R14
+	INCQ AX
+	JMP  inner
+
+innerDone:
+	// z = overflow + carry, with the top limb getting the low bits
+	MOVQ overflow-24(SP), R8
+	ADDQ R14, R8
+	MOVQ R8, R9
+	BTRQ $63, R9
+	MOVQ R9, -8(DI)(CX*8)
+	SHRQ $63, R8
+	MOVQ R8, overflow-24(SP)
+
+	ADDQ $8, SI
+	DECQ R15
+	JNZ  outer
+
+	MOVQ overflow-24(SP), R8
+	MOVQ R8, ret+104(FP)
+	RET
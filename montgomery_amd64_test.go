@@ -0,0 +1,84 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package ctrsa
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+func testMontgomeryLoopADX(a *nat, b *nat) bool {
+	size := len(a.limbs)
+	if len(b.limbs) < size {
+		size = len(b.limbs)
+	}
+	if size == 0 {
+		return true
+	}
+	mNat := (*nat)(nil).Generate(rand.New(rand.NewSource(int64(a.limbs[0]))), size).Interface().(*nat)
+	mNat.limbs[0] |= 1
+	mNat.limbs[size-1] |= 1
+	m := modulusFromNat(mNat)
+	x := new(nat).mod(&nat{a.limbs[:size]}, m)
+	y := new(nat).mod(&nat{b.limbs[:size]}, m)
+
+	expected := new(nat).expandFor(m)
+	expectedOverflow := montgomeryLoopGeneric(expected.limbs, x.limbs, y.limbs, m.nat.limbs, m.m0inv)
+	actual := new(nat).expandFor(m)
+	actualOverflow := montgomeryLoopADX(actual.limbs, x.limbs, y.limbs, m.nat.limbs, m.m0inv)
+	return actualOverflow == expectedOverflow && actual.cmpEq(expected) == 1
+}
+
+func TestMontgomeryLoopADX(t *testing.T) {
+	if !hasADX {
+		t.Skip("the CPU doesn't support ADX")
+	}
+	err := quick.Check(testMontgomeryLoopADX, &quick.Config{MaxCount: 10000})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMontgomeryLoopADXMaximal(t *testing.T) {
+	if !hasADX {
+		t.Skip("the CPU doesn't support ADX")
+	}
+	// Full limbs make for the largest carries
+	for size := 1; size <= 40; size++ {
+		m := &nat{make([]uint, size)}
+		x := &nat{make([]uint, size)}
+		for i := range m.limbs {
+			m.limbs[i] = _MASK
+			x.limbs[i] = _MASK - 1
+		}
+		expected := &nat{make([]uint, size)}
+		expectedOverflow := montgomeryLoopGeneric(expected.limbs, x.limbs, x.limbs, m.limbs, 1)
+		actual := &nat{make([]uint, size)}
+		actualOverflow := montgomeryLoopADX(actual.limbs, x.limbs, x.limbs, m.limbs, 1)
+		if actualOverflow != expectedOverflow || actual.cmpEq(expected) != 1 {
+			t.Errorf("%d limbs: %+v, %d != %+v, %d", size, actual, actualOverflow, expected, expectedOverflow)
+		}
+	}
+}
+
+func BenchmarkMontgomeryLoop(b *testing.B) {
+	x := makeBenchmarkValue()
+	m := makeBenchmarkModulus()
+	out := new(nat).expandFor(m)
+
+	b.Run("generic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			montgomeryLoopGeneric(out.limbs, x.limbs, x.limbs, m.nat.limbs, m.m0inv)
+		}
+	})
+	if !hasADX {
+		return
+	}
+	b.Run("adx", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			montgomeryLoopADX(out.limbs, x.limbs, x.limbs, m.nat.limbs, m.m0inv)
+		}
+	})
+}
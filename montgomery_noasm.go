@@ -0,0 +1,11 @@
+//go:build !amd64 || purego
+// +build !amd64 purego
+
+package ctrsa
+
+// montgomeryLoop accumulates xy / R into out, returning the bit overflowing out
+//
+// out must start off as zero, and all of the slices must have the same length.
+func montgomeryLoop(out, x, y, m []uint, m0inv uint) uint {
+	return montgomeryLoopGeneric(out, x, y, m, m0inv)
+}
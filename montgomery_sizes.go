@@ -0,0 +1,1534 @@
+// Code generated by gen_montgomery.go. DO NOT EDIT.
+
+package ctrsa
+
+import "math/bits"
+
+// montgomeryLoopFixed returns a version of montgomeryLoopGeneric specialized for size limbs, if there is one
+func montgomeryLoopFixed(size int) func(out, x, y, m []uint, m0inv uint) uint {
+	switch size {
+	case 33:
+		return montgomeryLoop33
+	case 49:
+		return montgomeryLoop49
+	case 66:
+		return montgomeryLoop66
+	}
+	return nil
+}
+
+// montgomeryLoop33 is montgomeryLoopAnySize, for exactly 33 limbs, with the inner loop unrolled
+func montgomeryLoop33(out, x, y, m []uint, m0inv uint) uint {
+	out, x, y, m = out[:33], x[:33], y[:33], m[:33]
+	overflow := uint(0)
+	for i := 0; i < 33; i++ {
+		xi := x[i]
+		f := ((out[0] + xi*y[0]) * m0inv) & _MASK
+		var carry, hi, lo, zLo, zHi, c uint
+		hi, lo = bits.Mul(xi, y[0])
+		zLo, c = bits.Add(out[0], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[0])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[1])
+		zLo, c = bits.Add(out[1], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[1])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[0] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[2])
+		zLo, c = bits.Add(out[2], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[2])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[1] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[3])
+		zLo, c = bits.Add(out[3], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[3])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[2] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[4])
+		zLo, c = bits.Add(out[4], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[4])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[3] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[5])
+		zLo, c = bits.Add(out[5], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[5])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[4] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[6])
+		zLo, c = bits.Add(out[6], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[6])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[5] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[7])
+		zLo, c = bits.Add(out[7], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[7])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[6] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[8])
+		zLo, c = bits.Add(out[8], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[8])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[7] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[9])
+		zLo, c = bits.Add(out[9], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[9])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[8] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[10])
+		zLo, c = bits.Add(out[10], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[10])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[9] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[11])
+		zLo, c = bits.Add(out[11], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[11])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[10] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[12])
+		zLo, c = bits.Add(out[12], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[12])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[11] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[13])
+		zLo, c = bits.Add(out[13], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[13])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[12] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[14])
+		zLo, c = bits.Add(out[14], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[14])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[13] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[15])
+		zLo, c = bits.Add(out[15], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[15])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[14] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[16])
+		zLo, c = bits.Add(out[16], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[16])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[15] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[17])
+		zLo, c = bits.Add(out[17], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[17])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[16] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[18])
+		zLo, c = bits.Add(out[18], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[18])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[17] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[19])
+		zLo, c = bits.Add(out[19], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[19])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[18] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[20])
+		zLo, c = bits.Add(out[20], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[20])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[19] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[21])
+		zLo, c = bits.Add(out[21], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[21])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[20] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[22])
+		zLo, c = bits.Add(out[22], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[22])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[21] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[23])
+		zLo, c = bits.Add(out[23], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[23])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[22] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[24])
+		zLo, c = bits.Add(out[24], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[24])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[23] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[25])
+		zLo, c = bits.Add(out[25], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[25])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[24] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[26])
+		zLo, c = bits.Add(out[26], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[26])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[25] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[27])
+		zLo, c = bits.Add(out[27], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[27])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[26] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[28])
+		zLo, c = bits.Add(out[28], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[28])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[27] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[29])
+		zLo, c = bits.Add(out[29], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[29])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[28] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[30])
+		zLo, c = bits.Add(out[30], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[30])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[29] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[31])
+		zLo, c = bits.Add(out[31], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[31])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[30] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[32])
+		zLo, c = bits.Add(out[32], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[32])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[31] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		z := overflow + carry
+		out[32] = z & _MASK
+		overflow = z >> _W
+	}
+	return overflow
+}
+
+// montgomeryLoop49 is montgomeryLoopAnySize, for exactly 49 limbs, with the inner loop unrolled
+func montgomeryLoop49(out, x, y, m []uint, m0inv uint) uint {
+	out, x, y, m = out[:49], x[:49], y[:49], m[:49]
+	overflow := uint(0)
+	for i := 0; i < 49; i++ {
+		xi := x[i]
+		f := ((out[0] + xi*y[0]) * m0inv) & _MASK
+		var carry, hi, lo, zLo, zHi, c uint
+		hi, lo = bits.Mul(xi, y[0])
+		zLo, c = bits.Add(out[0], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[0])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[1])
+		zLo, c = bits.Add(out[1], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[1])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[0] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[2])
+		zLo, c = bits.Add(out[2], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[2])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[1] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[3])
+		zLo, c = bits.Add(out[3], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[3])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[2] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[4])
+		zLo, c = bits.Add(out[4], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[4])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[3] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[5])
+		zLo, c = bits.Add(out[5], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[5])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[4] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[6])
+		zLo, c = bits.Add(out[6], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[6])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[5] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[7])
+		zLo, c = bits.Add(out[7], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[7])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[6] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[8])
+		zLo, c = bits.Add(out[8], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[8])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[7] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[9])
+		zLo, c = bits.Add(out[9], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[9])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[8] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[10])
+		zLo, c = bits.Add(out[10], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[10])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[9] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[11])
+		zLo, c = bits.Add(out[11], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[11])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[10] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[12])
+		zLo, c = bits.Add(out[12], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[12])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[11] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[13])
+		zLo, c = bits.Add(out[13], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[13])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[12] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[14])
+		zLo, c = bits.Add(out[14], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[14])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[13] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[15])
+		zLo, c = bits.Add(out[15], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[15])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[14] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[16])
+		zLo, c = bits.Add(out[16], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[16])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[15] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[17])
+		zLo, c = bits.Add(out[17], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[17])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[16] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[18])
+		zLo, c = bits.Add(out[18], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[18])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[17] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[19])
+		zLo, c = bits.Add(out[19], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[19])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[18] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[20])
+		zLo, c = bits.Add(out[20], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[20])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[19] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[21])
+		zLo, c = bits.Add(out[21], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[21])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[20] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[22])
+		zLo, c = bits.Add(out[22], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[22])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[21] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[23])
+		zLo, c = bits.Add(out[23], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[23])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[22] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[24])
+		zLo, c = bits.Add(out[24], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[24])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[23] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[25])
+		zLo, c = bits.Add(out[25], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[25])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[24] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[26])
+		zLo, c = bits.Add(out[26], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[26])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[25] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[27])
+		zLo, c = bits.Add(out[27], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[27])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[26] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[28])
+		zLo, c = bits.Add(out[28], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[28])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[27] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[29])
+		zLo, c = bits.Add(out[29], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[29])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[28] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[30])
+		zLo, c = bits.Add(out[30], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[30])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[29] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[31])
+		zLo, c = bits.Add(out[31], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[31])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[30] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[32])
+		zLo, c = bits.Add(out[32], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[32])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[31] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[33])
+		zLo, c = bits.Add(out[33], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[33])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[32] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[34])
+		zLo, c = bits.Add(out[34], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[34])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[33] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[35])
+		zLo, c = bits.Add(out[35], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[35])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[34] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[36])
+		zLo, c = bits.Add(out[36], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[36])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[35] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[37])
+		zLo, c = bits.Add(out[37], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[37])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[36] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[38])
+		zLo, c = bits.Add(out[38], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[38])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[37] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[39])
+		zLo, c = bits.Add(out[39], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[39])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[38] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[40])
+		zLo, c = bits.Add(out[40], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[40])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[39] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[41])
+		zLo, c = bits.Add(out[41], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[41])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[40] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[42])
+		zLo, c = bits.Add(out[42], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[42])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[41] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[43])
+		zLo, c = bits.Add(out[43], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[43])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[42] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[44])
+		zLo, c = bits.Add(out[44], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[44])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[43] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[45])
+		zLo, c = bits.Add(out[45], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[45])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[44] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[46])
+		zLo, c = bits.Add(out[46], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[46])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[45] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[47])
+		zLo, c = bits.Add(out[47], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[47])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[46] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[48])
+		zLo, c = bits.Add(out[48], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[48])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[47] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		z := overflow + carry
+		out[48] = z & _MASK
+		overflow = z >> _W
+	}
+	return overflow
+}
+
+// montgomeryLoop66 is montgomeryLoopAnySize, for exactly 66 limbs, with the inner loop unrolled
+func montgomeryLoop66(out, x, y, m []uint, m0inv uint) uint {
+	out, x, y, m = out[:66], x[:66], y[:66], m[:66]
+	overflow := uint(0)
+	for i := 0; i < 66; i++ {
+		xi := x[i]
+		f := ((out[0] + xi*y[0]) * m0inv) & _MASK
+		var carry, hi, lo, zLo, zHi, c uint
+		hi, lo = bits.Mul(xi, y[0])
+		zLo, c = bits.Add(out[0], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[0])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[1])
+		zLo, c = bits.Add(out[1], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[1])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[0] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[2])
+		zLo, c = bits.Add(out[2], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[2])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[1] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[3])
+		zLo, c = bits.Add(out[3], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[3])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[2] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[4])
+		zLo, c = bits.Add(out[4], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[4])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[3] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[5])
+		zLo, c = bits.Add(out[5], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[5])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[4] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[6])
+		zLo, c = bits.Add(out[6], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[6])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[5] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[7])
+		zLo, c = bits.Add(out[7], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[7])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[6] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[8])
+		zLo, c = bits.Add(out[8], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[8])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[7] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[9])
+		zLo, c = bits.Add(out[9], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[9])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[8] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[10])
+		zLo, c = bits.Add(out[10], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[10])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[9] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[11])
+		zLo, c = bits.Add(out[11], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[11])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[10] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[12])
+		zLo, c = bits.Add(out[12], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[12])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[11] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[13])
+		zLo, c = bits.Add(out[13], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[13])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[12] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[14])
+		zLo, c = bits.Add(out[14], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[14])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[13] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[15])
+		zLo, c = bits.Add(out[15], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[15])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[14] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[16])
+		zLo, c = bits.Add(out[16], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[16])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[15] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[17])
+		zLo, c = bits.Add(out[17], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[17])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[16] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[18])
+		zLo, c = bits.Add(out[18], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[18])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[17] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[19])
+		zLo, c = bits.Add(out[19], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[19])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[18] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[20])
+		zLo, c = bits.Add(out[20], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[20])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[19] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[21])
+		zLo, c = bits.Add(out[21], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[21])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[20] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[22])
+		zLo, c = bits.Add(out[22], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[22])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[21] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[23])
+		zLo, c = bits.Add(out[23], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[23])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[22] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[24])
+		zLo, c = bits.Add(out[24], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[24])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[23] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[25])
+		zLo, c = bits.Add(out[25], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[25])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[24] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[26])
+		zLo, c = bits.Add(out[26], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[26])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[25] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[27])
+		zLo, c = bits.Add(out[27], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[27])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[26] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[28])
+		zLo, c = bits.Add(out[28], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[28])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[27] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[29])
+		zLo, c = bits.Add(out[29], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[29])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[28] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[30])
+		zLo, c = bits.Add(out[30], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[30])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[29] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[31])
+		zLo, c = bits.Add(out[31], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[31])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[30] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[32])
+		zLo, c = bits.Add(out[32], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[32])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[31] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[33])
+		zLo, c = bits.Add(out[33], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[33])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[32] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[34])
+		zLo, c = bits.Add(out[34], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[34])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[33] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[35])
+		zLo, c = bits.Add(out[35], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[35])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[34] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[36])
+		zLo, c = bits.Add(out[36], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[36])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[35] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[37])
+		zLo, c = bits.Add(out[37], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[37])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[36] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[38])
+		zLo, c = bits.Add(out[38], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[38])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[37] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[39])
+		zLo, c = bits.Add(out[39], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[39])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[38] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[40])
+		zLo, c = bits.Add(out[40], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[40])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[39] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[41])
+		zLo, c = bits.Add(out[41], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[41])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[40] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[42])
+		zLo, c = bits.Add(out[42], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[42])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[41] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[43])
+		zLo, c = bits.Add(out[43], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[43])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[42] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[44])
+		zLo, c = bits.Add(out[44], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[44])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[43] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[45])
+		zLo, c = bits.Add(out[45], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[45])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[44] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[46])
+		zLo, c = bits.Add(out[46], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[46])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[45] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[47])
+		zLo, c = bits.Add(out[47], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[47])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[46] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[48])
+		zLo, c = bits.Add(out[48], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[48])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[47] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[49])
+		zLo, c = bits.Add(out[49], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[49])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[48] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[50])
+		zLo, c = bits.Add(out[50], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[50])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[49] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[51])
+		zLo, c = bits.Add(out[51], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[51])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[50] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[52])
+		zLo, c = bits.Add(out[52], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[52])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[51] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[53])
+		zLo, c = bits.Add(out[53], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[53])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[52] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[54])
+		zLo, c = bits.Add(out[54], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[54])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[53] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[55])
+		zLo, c = bits.Add(out[55], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[55])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[54] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[56])
+		zLo, c = bits.Add(out[56], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[56])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[55] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[57])
+		zLo, c = bits.Add(out[57], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[57])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[56] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[58])
+		zLo, c = bits.Add(out[58], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[58])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[57] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[59])
+		zLo, c = bits.Add(out[59], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[59])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[58] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[60])
+		zLo, c = bits.Add(out[60], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[60])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[59] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[61])
+		zLo, c = bits.Add(out[61], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[61])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[60] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[62])
+		zLo, c = bits.Add(out[62], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[62])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[61] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[63])
+		zLo, c = bits.Add(out[63], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[63])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[62] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[64])
+		zLo, c = bits.Add(out[64], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[64])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[63] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		hi, lo = bits.Mul(xi, y[65])
+		zLo, c = bits.Add(out[65], lo, 0)
+		zHi, _ = bits.Add(0, hi, c)
+		hi, lo = bits.Mul(f, m[65])
+		zLo, c = bits.Add(zLo, lo, 0)
+		zHi, _ = bits.Add(zHi, hi, c)
+		zLo, c = bits.Add(zLo, carry, 0)
+		zHi, _ = bits.Add(zHi, 0, c)
+		out[64] = zLo & _MASK
+		carry = (zLo >> _W) | (zHi << 1)
+		z := overflow + carry
+		out[65] = z & _MASK
+		overflow = z >> _W
+	}
+	return overflow
+}
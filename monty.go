@@ -0,0 +1,83 @@
+package ctrsa
+
+// montyNat is a nat in montgomery representation, i.e. xR mod m, for some modulus m
+//
+// Both types share the same layout, but keeping them separate means that the compiler
+// catches mixing the two representations up, like passing a montyNat to modAdd.
+// Conversions happen explicitly, through toMonty and fromMonty.
+type montyNat nat
+
+// newMontyNat returns a zero montyNat, with the announced length of m
+func newMontyNat(m *modulus) *montyNat {
+	return (*montyNat)(new(nat).expandFor(m))
+}
+
+// toMonty returns x in montgomery representation, leaving x untouched
+//
+// x must already be reduced modulo m, and share its announced length.
+func (x *nat) toMonty(m *modulus) *montyNat {
+	return (*montyNat)(x.clone().montgomeryRepresentation(m))
+}
+
+// fromMonty sets out = x / R mod m, converting x back from montgomery representation
+//
+// out is allowed to alias x. The output will be expanded to the correct size and overwritten.
+func (out *nat) fromMonty(x *montyNat, m *modulus) *nat {
+	one := new(nat).expandFor(m)
+	one.limbs[0] = 1
+	// By montgomery multiplying with 1, we get xR / R = x
+	return out.expandFor(m).montgomeryMulSafe((*nat)(x), one, m)
+}
+
+// mul sets out = xy / R mod m, keeping the product in montgomery representation
+//
+// All inputs should be the same length, and out must not alias x or y.
+func (out *montyNat) mul(x, y *montyNat, m *modulus) *montyNat {
+	(*nat)(out).montgomeryMul((*nat)(x), (*nat)(y), m)
+	return out
+}
+
+// mulLazy sets out = x * y mod m, like mul, but only partially reduced, see montgomeryMulLazy
+//
+// m must satisfy m.lazyCompatible, and the result is only smaller than 2m.
+func (out *montyNat) mulLazy(x, y *montyNat, m *modulus) *montyNat {
+	(*nat)(out).montgomeryMulLazy((*nat)(x), (*nat)(y), m)
+	return out
+}
+
+// mulMonty sets out = xy mod m, for x in montgomery representation, and y in the usual one
+//
+// Since xR * y / R = xy, this leaves montgomery representation along the way.
+// The output will be expanded to the correct size and overwritten, and must not alias x or y.
+func (out *nat) mulMonty(x *montyNat, y *nat, m *modulus) *nat {
+	return out.expandFor(m).montgomeryMul((*nat)(x), y, m)
+}
+
+// set sets x = y, with both having the same announced length
+func (x *montyNat) set(y *montyNat) *montyNat {
+	copy(x.limbs, y.limbs)
+	return x
+}
+
+// equal returns 1 if x and y represent the same value modulo m, and 0 otherwise
+//
+// Since x -> xR mod m is a bijection on the values reduced modulo m, comparing
+// the montgomery representations directly is enough, with no need to convert out.
+// This relies on both being fully reduced, which montgomeryMul guarantees with its
+// final subtraction, but montgomeryMulLazy doesn't: x and x + m are the same value,
+// but aren't equal here. Like cmpEq, this leaks nothing beyond the announced lengths.
+func (x *montyNat) equal(y *montyNat) choice {
+	return (*nat)(x).cmpEq((*nat)(y))
+}
+
+// assign sets x = y if on == 1, like nat.assign
+func (x *montyNat) assign(on choice, y *montyNat) *montyNat {
+	(*nat)(x).assign(on, (*nat)(y))
+	return x
+}
+
+// clear overwrites all of the limbs of x with zero, like nat.clear
+func (x *montyNat) clear() *montyNat {
+	(*nat)(x).clear()
+	return x
+}
@@ -1,6 +1,10 @@
 package ctrsa
 
 import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"io"
 	"math/big"
 	"math/bits"
 )
@@ -55,6 +59,35 @@ func ctGeq(x, y uint) choice {
 	return 1 ^ choice(carry)
 }
 
+// ctBytesEq returns 1 if a and b have the same length and contents, and 0 otherwise
+//
+// The lengths of a and b may be leaked, but nothing about their contents is.
+// Unlike bytes.Equal, this doesn't exit early at the first differing byte.
+func ctBytesEq(a, b []byte) choice {
+	if len(a) != len(b) {
+		return 0
+	}
+	var diff uint
+	for i := 0; i < len(a); i++ {
+		diff |= uint(a[i] ^ b[i])
+	}
+	return ctEq(diff, 0)
+}
+
+// ctCopyBytes copies src into dst if on == 1, and leaves dst untouched otherwise
+//
+// This is the byte slice analog of nat.assign, and leaks nothing about on, beyond
+// the lengths of the slices. It panics if dst and src have different lengths.
+func ctCopyBytes(on choice, dst, src []byte) {
+	if len(dst) != len(src) {
+		panic("ctrsa: ctCopyBytes: slices have different lengths")
+	}
+	mask := byte(-uint(on))
+	for i := 0; i < len(dst); i++ {
+		dst[i] ^= mask & (dst[i] ^ src[i])
+	}
+}
+
 // div calculates (hi:lo / d, hi:lo % d)
 //
 // Unlike bits.Div, this function does not leak any information about its inputs.
@@ -64,6 +97,9 @@ func ctGeq(x, y uint) choice {
 // that the quotient cannot fit in a uint. Instead, constant time selection should
 // be used to handle these edge cases.
 //
+// In particular, dividing by zero returns a quotient with all bits set, and lo
+// as the remainder, since removing d succeeds at every step.
+//
 // All of the inputs are over the full size of uint.
 func div(hi, lo, d uint) (quo uint, rem uint) {
 	// The rough idea is to iterate from high to low bits b,
@@ -109,13 +145,21 @@ type nat struct {
 }
 
 // expand makes sure that x uses exactly size limbs
+//
+// Growing x pads it with zero limbs, even if its backing array has stale limbs
+// left over from when x was larger, so this preserves the value of x. Shrinking x
+// drops its top limbs.
 func (x *nat) expand(size int) *nat {
 	if cap(x.limbs) < size {
 		newLimbs := make([]uint, size)
 		copy(newLimbs, x.limbs)
 		x.limbs = newLimbs
-	} else {
-		x.limbs = x.limbs[:size]
+		return x
+	}
+	extra := len(x.limbs)
+	x.limbs = x.limbs[:size]
+	for i := extra; i < size; i++ {
+		x.limbs[i] = 0
 	}
 	return x
 }
@@ -127,6 +171,17 @@ func (x *nat) clone() *nat {
 	return out
 }
 
+// clear overwrites all of the limbs of x with zero, keeping its announced length
+//
+// This is used to scrub secret intermediate values once we're done with them,
+// since the garbage collector won't zero them.
+func (x *nat) clear() *nat {
+	for i := 0; i < len(x.limbs); i++ {
+		x.limbs[i] = 0
+	}
+	return x
+}
+
 // natFromBig creates a new natural number from a big.Int
 //
 // The announced length of the resulting nat is based on the exact bit-length of the input.
@@ -153,13 +208,52 @@ func natFromBig(x *big.Int) *nat {
 	return out
 }
 
+// setBig sets x = b, with an announced length based on the exact bit-length of b
+//
+// This is natFromBig, reusing x.
+func (x *nat) setBig(b *big.Int) *nat {
+	x.limbs = natFromBig(b).limbs
+	return x
+}
+
+// setUint sets x = v
+//
+// A uint64 doesn't fit in a single limb, so the announced length of x is always
+// the number of limbs needed for 64 bits, regardless of the value of v.
+func (x *nat) setUint(v uint64) *nat {
+	x.expand((64 + _W - 1) / _W)
+	for i := 0; i < len(x.limbs); i++ {
+		x.limbs[i] = uint(v) & _MASK
+		v >>= _W
+	}
+	return x
+}
+
+// toBig converts this number into a big.Int
+func (x *nat) toBig() *big.Int {
+	bytes := x.fillBytes(make([]byte, (len(x.limbs)*_W+7)/8))
+	return new(big.Int).SetBytes(bytes)
+}
+
+// String formats this number in decimal
+//
+// This is only meant for debugging, and uses big.Int under the hood. It is not
+// constant-time, and shouldn't be used on secret values in production.
+func (x *nat) String() string {
+	return x.toBig().String()
+}
+
 // fillBytes writes out this number as big endian bytes to a buffer
 //
 // If the bytes are not large enough to contain the number, the output is truncated,
-// keeping the least significant bytes that do fit.
+// keeping the least significant bytes that do fit. If the bytes are larger than
+// necessary, the output is padded with leading zeros.
 func (x *nat) fillBytes(bytes []byte) []byte {
 	outI := len(bytes) - 1
 	fittingLimbs := len(bytes) * 8 / _W
+	if fittingLimbs > len(x.limbs) {
+		fittingLimbs = len(x.limbs)
+	}
 	var shift uint
 	for _, limb := range x.limbs[:fittingLimbs] {
 		// The number of bits to consume from this limb
@@ -180,10 +274,19 @@ func (x *nat) fillBytes(bytes []byte) []byte {
 			outI--
 			limb >>= 8
 		}
-		bytes[outI] = byte(limb)
+		// The remaining bits start off the next byte
+		if shift > 0 {
+			bytes[outI] = byte(limb)
+		}
 	}
-	// If all of the limbs fit in the bytes, we have nothing left to do
+	// If all of the limbs fit in the bytes, we just need to pad with zeros
 	if fittingLimbs >= len(x.limbs) {
+		if shift > 0 {
+			outI--
+		}
+		for ; outI >= 0; outI-- {
+			bytes[outI] = 0
+		}
 		return bytes
 	}
 	// Becuase of how we calculated fittingLimbs, only the last remaining limb
@@ -202,6 +305,65 @@ func (x *nat) fillBytes(bytes []byte) []byte {
 	return bytes
 }
 
+// bytes returns this number as big endian bytes, using as many bytes as the modulus m needs
+//
+// The number should be reduced modulo m, in which case nothing is truncated, and the
+// output is padded with leading zeros as necessary.
+func (x *nat) bytes(m *modulus) []byte {
+	return x.fillBytes(make([]byte, m.byteLen()))
+}
+
+var (
+	_ encoding.BinaryMarshaler   = (*nat)(nil)
+	_ encoding.BinaryUnmarshaler = (*nat)(nil)
+)
+
+var errNatEncoding = errors.New("crypto/rsa: invalid nat encoding")
+
+// natEncodingSize returns the number of bytes used to encode the value of a nat with a given number of limbs
+func natEncodingSize(limbs int) int {
+	return (limbs*_W + 7) / 8
+}
+
+// MarshalBinary encodes this number, along with its announced length
+//
+// The encoding is the number of limbs as a 4 byte big endian integer, followed by
+// the value of the number as big endian bytes, using just enough bytes to hold that
+// many limbs.
+func (x *nat) MarshalBinary() ([]byte, error) {
+	if uint64(len(x.limbs)) > uint64(^uint32(0)) {
+		return nil, errNatEncoding
+	}
+	out := make([]byte, 4+natEncodingSize(len(x.limbs)))
+	binary.BigEndian.PutUint32(out, uint32(len(x.limbs)))
+	x.fillBytes(out[4:])
+	return out, nil
+}
+
+// UnmarshalBinary decodes a number encoded with MarshalBinary, overwriting x
+//
+// This restores the announced length of the number, and rejects encodings that have
+// the wrong size, or whose value doesn't fit in that many limbs.
+func (x *nat) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errNatEncoding
+	}
+	size := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint64(len(data)) != uint64(natEncodingSize(int(size))) {
+		return errNatEncoding
+	}
+	decoded := natFromBytes(data)
+	// The padding bits in the first byte can spill into an extra limb, which needs to be empty
+	for i := int(size); i < len(decoded.limbs); i++ {
+		if decoded.limbs[i] != 0 {
+			return errNatEncoding
+		}
+	}
+	x.limbs = decoded.expand(int(size)).limbs
+	return nil
+}
+
 // natFromBytes converts a slice of big endian bytes into a nat
 //
 // The announced length of the output depends on the number of bytes in this slice.
@@ -220,12 +382,163 @@ func natFromBytes(bytes []byte) *nat {
 			shift -= _W
 			out.limbs[outI] &= _MASK
 			outI++
-			out.limbs[outI] = uint(bi) >> (8 - shift)
+			// If the bytes fill the limbs exactly, the last byte has nothing left over
+			if outI < len(out.limbs) {
+				out.limbs[outI] = uint(bi) >> (8 - shift)
+			}
+		}
+	}
+	return out
+}
+
+// natBuilder accumulates the big endian bytes of a nat, as they arrive in chunks
+//
+// This is meant for streaming parsers, which receive a large number, like an
+// RSA modulus, in pieces. Each byte gets shifted into the limbs built so far,
+// so the bytes themselves don't need to be kept around. The zero value is an
+// empty builder, ready to use.
+type natBuilder struct {
+	x   nat
+	len int
+}
+
+// Write appends big endian bytes to the number being built
+//
+// This implements io.Writer, and never fails. Only the length of p, and not its
+// contents, influences the timing of this function.
+func (b *natBuilder) Write(p []byte) (int, error) {
+	b.len += len(p)
+	b.x.expand((b.len*8 + _W - 1) / _W)
+	for _, v := range p {
+		carry := uint(v)
+		for i, limb := range b.x.limbs {
+			b.x.limbs[i] = (limb<<8 | carry) & _MASK
+			carry = limb >> (_W - 8)
 		}
 	}
+	return len(p), nil
+}
+
+// finalize returns the nat built from all of the bytes written so far
+//
+// The result matches natFromBytes on the concatenation of those bytes, including
+// its announced length. The builder gets reset, and can be reused.
+func (b *natBuilder) finalize() *nat {
+	out := &nat{b.x.limbs}
+	b.x.limbs = nil
+	b.len = 0
 	return out
 }
 
+// finalizeModulus is like finalize, followed by the checks of modulusFromBytes
+func (b *natBuilder) finalizeModulus() (*modulus, error) {
+	return modulusFromNatChecked(b.finalize())
+}
+
+// limbFromBytes extracts the ith limb of a slice of big endian bytes
+//
+// This returns the same value as natFromBytes(bytes).limbs[i], without
+// decoding the other limbs.
+func limbFromBytes(bytes []byte, i int) uint {
+	lo := i * _W
+	var out uint
+	for j := lo / 8; j <= (lo+_W-1)/8 && j < len(bytes); j++ {
+		b := uint(bytes[len(bytes)-1-j])
+		if shift := j*8 - lo; shift >= 0 {
+			out |= b << shift
+		} else {
+			out |= b >> -shift
+		}
+	}
+	return out & _MASK
+}
+
+// setBytes sets x to a slice of big endian bytes, reduced modulo m
+//
+// This produces the same result as new(nat).mod(natFromBytes(bytes), m), but
+// reuses the backing array of x, only allocating when it has fewer than m's limbs
+// of capacity. The limbs are decoded one at a time, and shifted into x, in the same
+// order that mod would use, so no intermediate nat is needed.
+//
+// The announced length of x will match that of m. This leaks nothing beyond
+// the length of bytes, and the announced length of m.
+func (x *nat) setBytes(bytes []byte, m *modulus) *nat {
+	x.expand(len(m.nat.limbs))
+	for i := 0; i < len(x.limbs); i++ {
+		x.limbs[i] = 0
+	}
+	i := (len(bytes)*8+_W-1)/_W - 1
+	// Like mod, we can inject up to N - 1 limbs directly, without reducing
+	start := len(m.nat.limbs) - 2
+	if i < start {
+		start = i
+	}
+	for j := start; j >= 0; j-- {
+		x.limbs[j] = limbFromBytes(bytes, i)
+		i--
+	}
+	for ; i >= 0; i-- {
+		x.shiftIn(limbFromBytes(bytes, i), m)
+	}
+	return x
+}
+
+var errNatOutOfRange = errors.New("crypto/rsa: number is not reduced modulo the modulus")
+
+// natFromBytesChecked converts a slice of big endian bytes into a nat reduced modulo m
+//
+// The result has the same announced length as m. An error is returned if the number
+// is not strictly smaller than m. Only the length of the bytes, and not their value,
+// influences the timing of this function.
+func natFromBytesChecked(bytes []byte, m *modulus) (*nat, error) {
+	out, inRange := natFromBytesInRange(bytes, m)
+	if inRange != 1 {
+		return nil, errNatOutOfRange
+	}
+	return out, nil
+}
+
+// natFromBytesInRange is like natFromBytesChecked, but returns whether the number is in range as a choice
+//
+// When the number isn't strictly smaller than m, the result is zero, instead of
+// an error, so that callers can fold the range check into their other checks.
+func natFromBytesInRange(bytes []byte, m *modulus) (*nat, choice) {
+	out := natFromBytes(bytes)
+	size := len(m.nat.limbs)
+	// Limbs past the size of the modulus come from leading zero bytes, or the
+	// number is too large. We check this without looking at which limb is set.
+	var excess uint
+	for i := size; i < len(out.limbs); i++ {
+		excess |= out.limbs[i]
+	}
+	out.expand(size)
+	inRange := ctEq(excess, 0) & out.cmpLt(m.nat)
+	out.assign(1^inRange, new(nat).expand(size))
+	return out, inRange
+}
+
+// randNatBelow returns a uniformly random nat in [0, m), reading randomness from rand
+//
+// This uses rejection sampling: bytes are read to fill the size of m, and the bits above
+// the size of m get cleared, so each attempt succeeds with probability at least 1/2.
+// Each attempt takes the same time, regardless of the value read. The result has
+// the same announced length as m.
+func randNatBelow(rand io.Reader, m *modulus) (*nat, error) {
+	bytes := make([]byte, m.byteLen())
+	excess := uint(len(bytes)*8 - m.bitLen())
+	for {
+		if _, err := io.ReadFull(rand, bytes); err != nil {
+			return nil, err
+		}
+		bytes[0] &= 0xFF >> excess
+		// Clearing the excess bits means that the extra limbs, if any, are zero
+		out := natFromBytes(bytes).expandFor(m)
+		if out.cmpGeq(m.nat) == 0 {
+			return out, nil
+		}
+	}
+}
+
 // cmpEq compares two natural numbers for equality
 //
 // Both operands must have the same announced length.
@@ -237,6 +550,31 @@ func (x *nat) cmpEq(y *nat) choice {
 	return equal
 }
 
+// isZero returns 1 if x is zero, and 0 otherwise
+//
+// This leaks no information about the value of x.
+func (x *nat) isZero() choice {
+	var acc uint
+	for i := 0; i < len(x.limbs); i++ {
+		acc |= x.limbs[i]
+	}
+	return ctEq(acc, 0)
+}
+
+// isOne returns 1 if x is one, and 0 otherwise
+//
+// This leaks no information about the value of x.
+func (x *nat) isOne() choice {
+	if len(x.limbs) == 0 {
+		return 0
+	}
+	acc := x.limbs[0] ^ 1
+	for i := 1; i < len(x.limbs); i++ {
+		acc |= x.limbs[i]
+	}
+	return ctEq(acc, 0)
+}
+
 // cmpGeq calculates x >= y, returning 1 if this holds, and 0 otherwise
 //
 // Both operands must have the same announced length
@@ -250,6 +588,14 @@ func (x *nat) cmpGeq(y *nat) choice {
 	return 1 ^ choice(c)
 }
 
+// cmpLt calculates x < y, returning 1 if this holds, and 0 otherwise
+//
+// This is the negation of cmpGeq, so x == y gives 0. Both operands must have
+// the same announced length.
+func (x *nat) cmpLt(y *nat) choice {
+	return 1 ^ x.cmpGeq(y)
+}
+
 // assign sets x <- y if on == 1, and does nothing otherwise
 //
 // Both operands must have the same announced length.
@@ -262,6 +608,20 @@ func (x *nat) assign(on choice, y *nat) *nat {
 	return x
 }
 
+// swap exchanges the values of x and y if on == 1, and does nothing otherwise
+//
+// Both operands must have the same announced length.
+//
+// No information is leaked about whether or not the swap happened.
+func (x *nat) swap(on choice, y *nat) {
+	mask := -uint(on)
+	for i := 0; i < len(x.limbs) && i < len(y.limbs); i++ {
+		t := mask & (x.limbs[i] ^ y.limbs[i])
+		x.limbs[i] ^= t
+		y.limbs[i] ^= t
+	}
+}
+
 // add comptues x += y, if on == 1, and does nothing otherwise
 //
 // Both operands must have the same announced length.
@@ -290,6 +650,248 @@ func (x *nat) sub(on choice, y *nat) (c uint) {
 	return
 }
 
+// subCarry computes x -= y, returning the borrow
+//
+// Unlike sub, the subtraction always happens. Both operands must have the same
+// announced length.
+func (x *nat) subCarry(y *nat) (c uint) {
+	for i := 0; i < len(x.limbs) && i < len(y.limbs); i++ {
+		res := x.limbs[i] - y.limbs[i] - c
+		x.limbs[i] = res & _MASK
+		c = res >> _W
+	}
+	return
+}
+
+// absDiff sets x = |x - y|
+//
+// Both differences get computed, and the borrow of x - y selects which one to keep,
+// so no information is leaked about which operand was larger. Both operands must
+// have the same announced length.
+func (x *nat) absDiff(y *nat) *nat {
+	yMinusX := y.clone()
+	yMinusX.subCarry(x)
+	c := x.subCarry(y)
+	x.assign(choice(c), yMinusX)
+	yMinusX.clear()
+	return x
+}
+
+// addWord computes x += y, for a single limb y, returning the carry out of x
+//
+// y must fit over _W bits. The carry propagates through every limb of x,
+// so this only leaks the announced length of x.
+func (x *nat) addWord(y uint) (c uint) {
+	c = y
+	for i := 0; i < len(x.limbs); i++ {
+		res := x.limbs[i] + c
+		x.limbs[i] = res & _MASK
+		c = res >> _W
+	}
+	return
+}
+
+// subWord computes x -= y, for a single limb y, returning the borrow out of x
+//
+// Like addWord, y must fit over _W bits, and only the announced length of x is leaked.
+func (x *nat) subWord(y uint) (c uint) {
+	c = y
+	for i := 0; i < len(x.limbs); i++ {
+		res := x.limbs[i] - c
+		x.limbs[i] = res & _MASK
+		c = res >> _W
+	}
+	return
+}
+
+// bit returns the i-th bit of x, either 0 or 1
+//
+// Bits past the announced length of x are 0. The index may be leaked,
+// but no information about the value of x is.
+func (x *nat) bit(i uint) uint {
+	limb := i / _W
+	if limb >= uint(len(x.limbs)) {
+		return 0
+	}
+	return (x.limbs[limb] >> (i % _W)) & 1
+}
+
+// trailingZeros returns the number of trailing zero bits in x
+//
+// Like big.Int.TrailingZeroBits, this returns 0 when x is 0.
+//
+// This is not constant-time: the running time, and of course the result, leak
+// the number of trailing zeros. Only use this on values which are public,
+// like the n - 1 of a candidate being tested for primality.
+func (x *nat) trailingZeros() uint {
+	for i, limb := range x.limbs {
+		if limb != 0 {
+			return uint(i)*_W + uint(bits.TrailingZeros(limb))
+		}
+	}
+	return 0
+}
+
+// bitLen returns the number of significant bits in x, like big.Int.BitLen
+//
+// This returns 0 when x is 0. Like trailingZeros, this is not constant-time, and
+// leaks the position of the top set bit of x. This is fine for public values, such
+// as generated primes, whose size gets checked against a target anyways.
+func (x *nat) bitLen() int {
+	for i := len(x.limbs) - 1; i >= 0; i-- {
+		if x.limbs[i] != 0 {
+			return i*_W + bits.Len(x.limbs[i])
+		}
+	}
+	return 0
+}
+
+// mulLow calculates out = x * y mod 2^(size * _W), i.e. the lowest size limbs of the product
+//
+// The output will be expanded and overwritten to have size limbs. It shouldn't alias
+// either of the inputs.
+//
+// This only leaks the announced lengths of x and y, and not their values.
+func (out *nat) mulLow(x, y *nat, size int) *nat {
+	out.expand(size)
+	for i := 0; i < size; i++ {
+		out.limbs[i] = 0
+	}
+	for i := 0; i < len(x.limbs) && i < size; i++ {
+		var carry uint
+		j := 0
+		for ; j < len(y.limbs) && i+j < size; j++ {
+			// x * y + out + carry < 2^127, so this all fits into hi:lo
+			hi, lo := bits.Mul(x.limbs[i], y.limbs[j])
+			var c uint
+			lo, c = bits.Add(lo, out.limbs[i+j], 0)
+			hi += c
+			lo, c = bits.Add(lo, carry, 0)
+			hi += c
+			out.limbs[i+j] = lo & _MASK
+			carry = (hi << 1) | (lo >> _W)
+		}
+		if i+j < size {
+			out.limbs[i+j] = carry
+		}
+	}
+	return out
+}
+
+// mul calculates out = x * y
+//
+// The output will be expanded and overwritten to have len(x) + len(y) limbs, which
+// is always enough to hold the product. It shouldn't alias either of the inputs.
+//
+// This uses schoolbook multiplication, switching to Karatsuba multiplication for large
+// operands of the same length. Either way, this only leaks the announced lengths of
+// x and y, and not their values, so it's fine to use on secret values as well as
+// public ones, like the product of two primes.
+func (out *nat) mul(x, y *nat) *nat {
+	if len(x.limbs) == len(y.limbs) && len(x.limbs) >= karatsubaThreshold {
+		return out.mulKaratsuba(x, y)
+	}
+	return out.mulLow(x, y, len(x.limbs)+len(y.limbs))
+}
+
+// shiftRight calculates x >>= n
+//
+// The announced length of x stays the same. The shift amount may be leaked,
+// but no information about the value of x is.
+func (x *nat) shiftRight(n uint) *nat {
+	limbShift := int(n / _W)
+	bitShift := n % _W
+	size := len(x.limbs)
+	for i := 0; i < size; i++ {
+		var lo, hi uint
+		if i+limbShift < size {
+			lo = x.limbs[i+limbShift]
+		}
+		if i+limbShift+1 < size {
+			hi = x.limbs[i+limbShift+1]
+		}
+		// When bitShift is 0, hi gets shifted entirely out of the mask
+		x.limbs[i] = ((lo >> bitShift) | (hi << (_W - bitShift))) & _MASK
+	}
+	return x
+}
+
+// shiftLeft calculates x <<= n
+//
+// The announced length of x grows by as many limbs as needed to hold the result.
+// The shift amount may be leaked, but no information about the value of x is.
+func (x *nat) shiftLeft(n uint) *nat {
+	limbShift := int(n / _W)
+	bitShift := n % _W
+	size := len(x.limbs)
+	newSize := size + int((n+_W-1)/_W)
+	x.expand(newSize)
+	// Going from the top down means that we only overwrite limbs we no longer need
+	for i := newSize - 1; i >= 0; i-- {
+		var lo, hi uint
+		src := i - limbShift
+		if src >= 0 && src < size {
+			hi = x.limbs[src]
+		}
+		if src >= 1 && src-1 < size {
+			lo = x.limbs[src-1]
+		}
+		// When bitShift is 0, lo gets shifted entirely away
+		x.limbs[i] = ((hi << bitShift) | (lo >> (_W - bitShift))) & _MASK
+	}
+	return x
+}
+
+// gcd calculates the greatest common divisor of a and b
+//
+// The result has the announced length of the larger operand. This uses the binary
+// GCD algorithm, running for a fixed number of iterations, based only on the announced
+// lengths of a and b, without branching on their values.
+func gcd(a, b *nat) *nat {
+	size := len(a.limbs)
+	if len(b.limbs) > size {
+		size = len(b.limbs)
+	}
+	a = a.clone().expand(size)
+	b = b.clone().expand(size)
+	scratch := new(nat).expand(size)
+	totalBits := 2 * size * _W
+
+	// First, remove the largest power of 2 dividing both a and b, keeping track of it in k
+	var k uint
+	for i := 0; i < totalBits; i++ {
+		bothEven := (1 ^ choice(a.limbs[0]&1)) & (1 ^ choice(b.limbs[0]&1))
+		scratch.assign(1, a)
+		a.assign(bothEven, scratch.shiftRight(1))
+		scratch.assign(1, b)
+		b.assign(bothEven, scratch.shiftRight(1))
+		k += uint(bothEven)
+	}
+
+	// Now, at least one of them is odd, unless both are 0, and we make sure that a is odd
+	a.swap(1^choice(a.limbs[0]&1), b)
+
+	// Each subtraction makes b even, and the next iteration will then shift it,
+	// so every two iterations, the total number of bits in a and b decreases.
+	for i := 0; i < 2*totalBits; i++ {
+		bOdd := choice(b.limbs[0] & 1)
+		// If b is odd, we make sure that b >= a, and then do b -= a
+		bSmaller := b.cmpLt(a)
+		a.swap(bOdd&bSmaller, b)
+		b.sub(bOdd, a)
+		// If b is even, we can remove a factor of 2, since a is odd
+		scratch.assign(1, b)
+		b.assign(1^bOdd, scratch.shiftRight(1))
+	}
+
+	// a now holds the odd part of the gcd, and we need to add back the factors of 2.
+	// The result is no larger than either operand, so this doesn't overflow.
+	for i := 0; i < totalBits; i++ {
+		a.add(1^ctGeq(uint(i), k), a)
+	}
+	return a
+}
+
 // mulSub calculates x -= q * m, producing a carry value
 //
 // Both nat operands must have the same length
@@ -312,17 +914,24 @@ func (x *nat) mulSub(q uint, m *nat) (cc uint) {
 // number of bits needed to store their value, and are stored without padding.
 //
 // Their actual value is still kept secret.
+//
+// A modulus is immutable once created: every operation only reads from it, so it's
+// fine to create one modulus, and then share it between many operations, including
+// across goroutines, as long as nobody writes to its fields directly.
 type modulus struct {
 	// The underlying natural number for this modulus.
 	//
-	// This will be stored without any padding.
-	//
-	// The contract here is that this shouldn't alias with any other natural number being used.
+	// This will be stored without any padding. modulusFromNat makes its own copy,
+	// so this never aliases with any other natural number being used.
 	nat *nat
 	// The number of leading zeros in the modulus
 	leading uint
 	// -nat.limbs[0]^-1 mod _W
 	m0inv uint
+	// R mod m, i.e. 1 in montgomery representation, with R := _W^n, and n = len(m)
+	r *montyNat
+	// R^2 mod m, used to convert numbers into montgomery representation
+	rr *nat
 }
 
 // minusInverseModW computes -x^(-1) mod _W
@@ -340,10 +949,25 @@ func minusInverseModW(x uint) uint {
 // modulusFromNat creates a new modulus from a nat
 //
 // The nat should be odd, nonzero, and the number of significant bits in the number should be
-// leakable.
+// leakable. For an even nat, the result is a garbage modulus, since Montgomery multiplication
+// requires the modulus to be odd, and for a zero nat, this panics. Use modulusFromNatChecked
+// for values that haven't already been checked.
 //
-// The nat shouldn't be modified as long as the modulus is being used.
+// The nat is copied, so it can be modified or cleared afterwards, without affecting the modulus.
 func modulusFromNat(nat *nat) *modulus {
+	return newModulus(nat.clone())
+}
+
+// modulusFromProduct creates a new modulus for n = p * q, like the modulus of an RSA key
+//
+// p and q should be odd and nonzero, like the input to modulusFromNat. The product
+// becomes the modulus directly, without an intermediate copy, and p and q are left untouched.
+func modulusFromProduct(p, q *nat) *modulus {
+	return newModulus(new(nat).mul(p, q))
+}
+
+// newModulus creates a new modulus, like modulusFromNat, but takes ownership of nat instead of copying it
+func newModulus(nat *nat) *modulus {
 	var m modulus
 	m.nat = nat
 	// Remove any leading zeros
@@ -351,11 +975,89 @@ func modulusFromNat(nat *nat) *modulus {
 	for size = uint(len(m.nat.limbs)); size > 0 && m.nat.limbs[size-1] == 0; size-- {
 	}
 	m.nat.limbs = m.nat.limbs[:size]
-	m.leading = uint(bits.LeadingZeros(m.nat.limbs[size-1]) - 1)
+	m.leading = uint(int(size)*_W - m.nat.bitLen())
 	m.m0inv = minusInverseModW(m.nat.limbs[0])
+
+	// R = _W^n has n + 1 limbs, and R^2 has 2n + 1 limbs, with only their top limb set
+	r := m.nat.clone().expand(int(size + 1)).clear()
+	r.limbs[size] = 1
+	m.r = (*montyNat)(m.nat.clone().mod(r, &m))
+	rr := m.nat.clone().expand(int(2*size + 1)).clear()
+	rr.limbs[2*size] = 1
+	m.rr = m.nat.clone().mod(rr, &m)
 	return &m
 }
 
+var (
+	errModulusZero = keyError("crypto/rsa: modulus must not be zero")
+	errModulusEven = keyError("crypto/rsa: modulus must be odd")
+)
+
+// modulusFromNatChecked creates a new modulus from a nat, returning an error if the nat is unusable
+//
+// This checks that the nat is nonzero and odd, which leaks its least significant bit, but that bit
+// would need to be 1 for a valid modulus anyways.
+func modulusFromNatChecked(nat *nat) (*modulus, error) {
+	if nat.isZero() == 1 {
+		return nil, errModulusZero
+	}
+	if nat.limbs[0]&1 == 0 {
+		return nil, errModulusEven
+	}
+	return modulusFromNat(nat), nil
+}
+
+// modulusFromBytes creates a new modulus from a slice of big endian bytes, like an RSA modulus
+//
+// Leading zero bytes are trimmed, and the same checks as modulusFromNatChecked apply.
+func modulusFromBytes(bytes []byte) (*modulus, error) {
+	return modulusFromNatChecked(natFromBytes(bytes))
+}
+
+// String formats the value of this modulus in decimal
+//
+// Like nat.String, this is only meant for debugging, and shouldn't be used on
+// secret values in production.
+func (m *modulus) String() string {
+	return m.nat.String()
+}
+
+// bitLen returns the number of bits needed to represent the modulus
+func (m *modulus) bitLen() int {
+	return len(m.nat.limbs)*_W - int(m.leading)
+}
+
+// byteLen returns the number of bytes needed to represent the modulus
+func (m *modulus) byteLen() int {
+	return (m.bitLen() + 7) / 8
+}
+
+// alignedClones returns copies of x and y, padded to have the same announced length
+func alignedClones(x, y *nat) (*nat, *nat) {
+	size := len(x.limbs)
+	if len(y.limbs) > size {
+		size = len(y.limbs)
+	}
+	return x.clone().expand(size), y.clone().expand(size)
+}
+
+// equal returns 1 if m and other have the same value, and 0 otherwise
+//
+// The moduli may have different announced lengths, which may be leaked,
+// but no information about their values is.
+func (m *modulus) equal(other *modulus) choice {
+	x, y := alignedClones(m.nat, other.nat)
+	return x.cmpEq(y)
+}
+
+// less returns 1 if m < other, and 0 otherwise
+//
+// Like equal, this only leaks the announced lengths of the moduli.
+func (m *modulus) less(other *modulus) choice {
+	x, y := alignedClones(m.nat, other.nat)
+	return x.cmpLt(y)
+}
+
 // shiftIn calculates x = x << _W + y mod m
 //
 // This assumes that x is already reduced mod m.
@@ -440,6 +1142,21 @@ func (out *nat) mod(x *nat, m *modulus) *nat {
 	return out
 }
 
+// modWord returns x mod d, for a single word divisor d
+//
+// This doesn't leak the value of x or d, beyond the announced length of x.
+// Callers may then leak the result, when x is public, as is the case when sieving
+// out candidates during prime generation. d must not be zero.
+func (x *nat) modWord(d uint) uint {
+	var r uint
+	for i := len(x.limbs) - 1; i >= 0; i-- {
+		// r:x_i = r * 2^_W + x_i, and div expects this as two full uint words.
+		// Since r < d, the quotient always fits in a uint.
+		_, r = div(r>>1, (r<<_W)|x.limbs[i], d)
+	}
+	return r
+}
+
 // expandFor makes sure that out has the right size to work with operations modulo m
 //
 // This assumes that out is already reduced modulo m, but may not be properly sized. Since
@@ -449,13 +1166,23 @@ func (out *nat) expandFor(m *modulus) *nat {
 	return out.expand(len(m.nat.limbs))
 }
 
+// maybeSubtract sets x = x - m if x >= m, reducing a value in [0, 2m) modulo m
+//
+// x must have the announced length of m. No information is leaked about whether
+// or not the subtraction happened.
+func (x *nat) maybeSubtract(m *modulus) {
+	x.sub(x.cmpGeq(m.nat), m.nat)
+}
+
 // modSub computes x = (x - y) % m
 //
 // The length of both operands must be the same as the modulus.
 //
 // Both operands must already be reduced modulo m.
 func (x *nat) modSub(y *nat, m *modulus) *nat {
-	underflow := x.sub(1, y)
+	assertReduced("modSub", x, m)
+	assertReduced("modSub", y, m)
+	underflow := x.subCarry(y)
 	// If an underflow occurred, then adding m is sufficient to get the right result
 	x.add(choice(underflow), m.nat)
 	return x
@@ -467,9 +1194,11 @@ func (x *nat) modSub(y *nat, m *modulus) *nat {
 //
 // Both operands must already be reduced modulo m.
 func (x *nat) modAdd(y *nat, m *modulus) *nat {
+	assertReduced("modAdd", x, m)
+	assertReduced("modAdd", y, m)
 	overflow := x.add(1, y)
 	// If x < m, then subtraction will underflow
-	underflow := 1 ^ x.cmpGeq(m.nat)
+	underflow := x.cmpLt(m.nat)
 	// Three cases are possible:
 	//
 	// overflow = 0, underflow = 0
@@ -495,112 +1224,706 @@ func (x *nat) modAdd(y *nat, m *modulus) *nat {
 //
 // Montgomery multiplication replaces standard modular multiplication for numbers
 // in this representation. This speeds up the multiplication operation in this case.
+//
+// x should already be reduced modulo m, and have the same size.
 func (x *nat) montgomeryRepresentation(m *modulus) *nat {
-	for i := 0; i < len(m.nat.limbs); i++ {
-		x.shiftIn(0, m)
-	}
-	return x
+	// Montgomery multiplying by R^2 gives us xR^2 / R = xR
+	return x.montgomeryMulSafe(x, m.rr, m)
 }
 
 // montgomeryMul calculates out = xy / R % m, with R := _W^n, and n = len(m)
 //
 // This is faster than your standard modular multiplication.
 //
-// All inputs should be the same length, and not alias eachother.
+// All inputs should be the same length, and out must not alias x or y, otherwise
+// the result is silently wrong. See montgomeryMulSafe for a variant allowing this.
 func (out *nat) montgomeryMul(x *nat, y *nat, m *modulus) *nat {
+	assertReduced("montgomeryMul", x, m)
+	assertReduced("montgomeryMul", y, m)
+	countMontgomeryMul()
+	for i := 0; i < len(out.limbs); i++ {
+		out.limbs[i] = 0
+	}
+
+	overflow := montgomeryLoop(out.limbs, x.limbs, y.limbs, m.nat.limbs, m.m0inv)
+	underflow := out.cmpLt(m.nat)
+	// See modAdd
+	needSubtraction := ctEq(overflow, uint(underflow))
+	out.sub(needSubtraction, m.nat)
+	return out
+}
+
+// montgomeryReduce calculates out = t / R % m, with R := _W^n, and n = len(m)
+//
+// This is the REDC half of montgomeryMul on its own: t is an already computed product
+// of 2n limbs, from mul for example, and must be smaller than mR, which is the case for
+// the product of two numbers reduced modulo m. This lets the multiplication happen
+// separately, with Karatsuba or in assembly. t isn't modified, and out gets expanded
+// to have n limbs. This leaks nothing beyond the announced lengths of t and m.
+func (out *nat) montgomeryReduce(t *nat, m *modulus) *nat {
+	n := len(m.nat.limbs)
+	if len(t.limbs) != 2*n {
+		panic("ctrsa: montgomeryReduce: input must have twice as many limbs as the modulus")
+	}
+	T := make([]uint, 2*n)
+	copy(T, t.limbs)
+	overflow := montgomeryReduceLimbs(T, m)
+	out.expand(n)
+	copy(out.limbs, T[n:])
+	// T held a copy of t, and then the result, both of which may be secret
+	for i := range T {
+		T[i] = 0
+	}
+	underflow := out.cmpLt(m.nat)
+	// See modAdd
+	needSubtraction := ctEq(overflow, uint(underflow))
+	out.sub(needSubtraction, m.nat)
+	return out
+}
+
+// montgomeryReduceLimbs replaces the top half of T, of 2n limbs, with T / R, returning the bit overflowing it
+//
+// The bottom half of T gets cleared along the way. The result is smaller than 2m,
+// when T is smaller than mR, and still needs a final subtraction of m.
+func montgomeryReduceLimbs(T []uint, m *modulus) uint {
+	n := len(m.nat.limbs)
+	// Each iteration clears T[i], by adding a multiple of m, and the carry out of
+	// position i + n gets added in at the next iteration, or ends up in overflow.
+	overflow := uint(0)
+	mLimbs := m.nat.limbs
+	for i := 0; i < n; i++ {
+		// Reslicing lets the compiler elide bounds checks in the inner loop
+		Ti := T[i : i+len(mLimbs)]
+		f := (Ti[0] * m.m0inv) & _MASK
+		var carry uint
+		for j, mj := range mLimbs {
+			hi, lo := bits.Mul(f, mj)
+			var c uint
+			lo, c = bits.Add(lo, Ti[j], 0)
+			hi += c
+			lo, c = bits.Add(lo, carry, 0)
+			hi += c
+			Ti[j] = lo & _MASK
+			carry = (hi << 1) | (lo >> _W)
+		}
+		// This sum can just barely exceed a full word, so we keep track of that carry too
+		z, c1 := bits.Add(T[i+n], carry, 0)
+		z, c2 := bits.Add(z, overflow, 0)
+		T[i+n] = z & _MASK
+		overflow = (z >> _W) | ((c1 + c2) << 1)
+	}
+	return overflow
+}
+
+// montgomeryMulLazy calculates out = xy / R mod m, leaving the result only partially reduced
+//
+// This skips the final subtraction of montgomeryMul, which lets a chain of multiplications
+// defer the reduction to a single finalReduce at the end. The inputs only need
+// to be smaller than 2m, and the output is then also smaller than 2m, but possibly not m.
+//
+// This requires 4m <= R, i.e. at least 2 leading zero bits in the top limb of m,
+// and panics otherwise: callers should check m.lazyCompatible first. The loop accumulates (xy + fm) / R, with f < R,
+// so x, y < 2m gives a result smaller than (4m^2 + Rm) / R <= m + m = 2m. That
+// result also never overflows out, since 2m < R.
+//
+// All inputs should be the same length, and out must not alias x or y.
+func (out *nat) montgomeryMulLazy(x *nat, y *nat, m *modulus) *nat {
+	if !m.lazyCompatible() {
+		panic("ctrsa: montgomeryMulLazy: modulus needs 2 leading zero bits")
+	}
 	for i := 0; i < len(out.limbs); i++ {
 		out.limbs[i] = 0
 	}
+	montgomeryLoop(out.limbs, x.limbs, y.limbs, m.nat.limbs, m.m0inv)
+	return out
+}
+
+// finalReduce takes x < 2m, as produced by montgomeryMulLazy, and reduces it modulo m
+func (x *nat) finalReduce(m *modulus) *nat {
+	x.maybeSubtract(m)
+	return x
+}
+
+// lazyCompatible returns true if montgomeryMulLazy can be used with this modulus
+//
+// This only depends on the bit length of m, which is public.
+func (m *modulus) lazyCompatible() bool {
+	return m.leading >= 2
+}
+
+// montgomeryLoopGeneric accumulates xy / R into out, returning the bit overflowing out
+//
+// out must start off as zero, and all of the slices must have the same length.
+// This is the portable implementation of montgomeryLoop. Common RSA sizes use
+// specializations with a fixed number of limbs, the rest uses montgomeryLoopAnySize.
+func montgomeryLoopGeneric(out, x, y, m []uint, m0inv uint) uint {
+	if fixed := montgomeryLoopFixed(len(m)); fixed != nil {
+		return fixed(out, x, y, m, m0inv)
+	}
+	return montgomeryLoopAnySize(out, x, y, m, m0inv)
+}
 
+//go:generate go run gen_montgomery.go
+
+// montgomeryLoopAnySize is montgomeryLoopGeneric, for slices of any length
+func montgomeryLoopAnySize(out, x, y, m []uint, m0inv uint) uint {
 	overflow := uint(0)
 	// The different loops are over the same size, but we use different conditions
 	// to try and make the compiler elide bounds checking.
-	for i := 0; i < len(x.limbs); i++ {
-		f := ((out.limbs[0] + x.limbs[i]*y.limbs[0]) * m.m0inv) & _MASK
+	for i := 0; i < len(x); i++ {
+		f := ((out[0] + x[i]*y[0]) * m0inv) & _MASK
 		// Carry fits on 64 bits
 		var carry uint
-		for j := 0; j < len(y.limbs) && j < len(m.nat.limbs) && j < len(out.limbs); j++ {
-			hi, lo := bits.Mul(x.limbs[i], y.limbs[j])
-			z_lo, c := bits.Add(out.limbs[j], lo, 0)
+		for j := 0; j < len(y) && j < len(m) && j < len(out); j++ {
+			hi, lo := bits.Mul(x[i], y[j])
+			z_lo, c := bits.Add(out[j], lo, 0)
 			z_hi, _ := bits.Add(0, hi, c)
-			hi, lo = bits.Mul(f, m.nat.limbs[j])
+			hi, lo = bits.Mul(f, m[j])
 			z_lo, c = bits.Add(z_lo, lo, 0)
 			z_hi, _ = bits.Add(z_hi, hi, c)
 			z_lo, c = bits.Add(z_lo, carry, 0)
 			z_hi, _ = bits.Add(z_hi, 0, c)
 			if j > 0 {
-				out.limbs[j-1] = z_lo & _MASK
+				out[j-1] = z_lo & _MASK
 			}
 			carry = (z_lo >> _W) | (z_hi << 1)
 		}
 		z := overflow + carry
-		out.limbs[len(out.limbs)-1] = z & _MASK
+		out[len(out)-1] = z & _MASK
 		overflow = z >> _W
 	}
-	underflow := 1 ^ out.cmpGeq(m.nat)
-	// See modAdd
-	needSubtraction := ctEq(overflow, uint(underflow))
-	out.sub(needSubtraction, m.nat)
-	return out
+	return overflow
+}
+
+// aliases reports whether x and y share the same underlying limbs
+//
+// This only detects slices starting at the same limb, which is how aliasing
+// happens in practice, when the same nat gets passed twice.
+func (x *nat) aliases(y *nat) bool {
+	return len(x.limbs) > 0 && len(y.limbs) > 0 && &x.limbs[0] == &y.limbs[0]
+}
+
+// montgomeryMulSafe calculates out = xy / R % m, like montgomeryMul
+//
+// Unlike montgomeryMul, out is allowed to alias x or y, or both, in which case
+// the aliased inputs are cloned first. Whether or not aliasing happens only depends
+// on which nats are passed, and not their values.
+func (out *nat) montgomeryMulSafe(x *nat, y *nat, m *modulus) *nat {
+	xAliased, yAliased := out.aliases(x), out.aliases(y)
+	switch {
+	case xAliased && yAliased:
+		x = x.clone()
+		y = x
+	case xAliased:
+		x = x.clone()
+	case yAliased:
+		y = y.clone()
+	}
+	return out.montgomeryMul(x, y, m)
 }
 
 // modMul calculates x *= y mod m
 //
 // Both operands must already be reduced modulo m, and share its announced length.
 func (x *nat) modMul(y *nat, m *modulus) *nat {
-	xMonty := x.clone().montgomeryRepresentation(m)
-	x.montgomeryMul(xMonty, y, m)
+	assertReduced("modMul", x, m)
+	assertReduced("modMul", y, m)
+	xMonty := x.toMonty(m)
+	x.mulMonty(xMonty, y, m)
+	xMonty.clear()
 	return x
 }
 
-// exp calculates out <- x^e modulo m
+// modInverse calculates out = x^-1 mod m, also returning 1 if this inverse exists
 //
-// The exponent, e, is presented as bytes in big endian order.
+// x must already be reduced modulo m, and share its announced length. Since m is odd,
+// the inverse exists exactly when gcd(x, m) = 1, and the output is garbage otherwise.
+//
+// Like gcd, this uses a binary algorithm, running for a fixed number of iterations,
+// based only on the announced length of m.
 //
 // The output will be expanded to the correct size and overwritten.
-func (out *nat) exp(x *nat, e []byte, m *modulus) *nat {
+func (out *nat) modInverse(x *nat, m *modulus) (*nat, choice) {
 	size := len(m.nat.limbs)
+	// We maintain a = ux and b = vx mod m, with b always odd
+	a := x.clone().expand(size)
+	b := m.nat.clone()
+	u := new(nat).expand(size)
+	u.limbs[0] = 1
+	v := new(nat).expand(size)
+	scratch := new(nat).expand(size)
+	// Each iteration halves a, so the total number of bits in a and b decreases
+	for i := 0; i < 2*size*_W; i++ {
+		aOdd := choice(a.limbs[0] & 1)
+		// If a is odd, we make sure that a >= b, and then do a -= b, making it even
+		aSmaller := a.cmpLt(b)
+		a.swap(aOdd&aSmaller, b)
+		u.swap(aOdd&aSmaller, v)
+		a.sub(aOdd, b)
+		scratch.assign(1, u)
+		u.assign(aOdd, scratch.modSub(v, m))
+		// Halving u modulo m requires making it even first, by adding m if necessary
+		a.shiftRight(1)
+		uOdd := choice(u.limbs[0] & 1)
+		c := u.add(uOdd, m.nat) & uint(uOdd)
+		u.shiftRight(1)
+		u.limbs[size-1] |= c << (_W - 1)
+	}
+	// a is now 0, and b holds gcd(x, m)
 	out.expand(size)
+	copy(out.limbs, v.limbs)
+	return out, b.isOne()
+}
 
-	// We use 4 bit windows. For our RSA workload, 4 bit windows are
-	// faster than 2 bit windows, but use an extra 12 nats worth of scratch space.
-	// Using bit sizes that don't divide 8 are a bit awkward to implement.
-	xs := make([]*nat, 15)
-	xs[0] = x.clone()
-	xs[0].montgomeryRepresentation(m)
-	for i := 1; i < len(xs); i++ {
-		xs[i] = &nat{make([]uint, size)}
-		xs[i].montgomeryMul(xs[i-1], xs[0], m)
+// modInverseWord calculates x^-1 mod m, for single words, also returning 1 if this inverse exists
+//
+// m must be odd and fit over _W bits, and x must be reduced modulo m. This follows
+// the same binary algorithm as modInverse, without leaking the values of x or m.
+func modInverseWord(x, m uint) (uint, choice) {
+	a, b := x, m
+	var u, v uint = 1, 0
+	for i := 0; i < 2*_W; i++ {
+		aOdd := choice(a & 1)
+		// If a is odd, we make sure that a >= b, and then do a -= b, making it even
+		swap := aOdd & (1 ^ ctGeq(a, b))
+		a, b = ctIfElse(swap, b, a), ctIfElse(swap, a, b)
+		u, v = ctIfElse(swap, v, u), ctIfElse(swap, u, v)
+		a = ctIfElse(aOdd, a-b, a)
+		u = ctIfElse(aOdd, ctIfElse(ctGeq(u, v), u-v, u-v+m), u)
+		// Halving u modulo m requires making it even first, by adding m if necessary
+		a >>= 1
+		u = ctIfElse(choice(u&1), u+m, u) >> 1
 	}
+	return v, ctEq(b, 1)
+}
 
-	selectedX := &nat{make([]uint, size)}
-	for i := 0; i < len(out.limbs); i++ {
-		out.limbs[i] = 0
+var errNoInverse = errors.New("crypto/rsa: public exponent is not invertible")
+
+// modInverseEven calculates e^-1 mod lambda, where lambda may be even, like lcm(p-1, q-1)
+//
+// modInverse needs an odd modulus, so instead this uses the binary extended Euclidean
+// algorithm modulo e, which is necessarily odd when an inverse exists, to find
+// y = lambda^-1 mod e. Then 1 + lambda * (e - y) is a multiple of e, and dividing
+// it by e produces the inverse, which is already reduced modulo lambda.
+//
+// e is public, must be at least 3, and fit over _W bits. Apart from whether or not
+// the inverse exists, this doesn't leak anything about lambda beyond its announced length.
+// The output has the announced length of lambda.
+func modInverseEven(e uint, lambda *nat) (*nat, error) {
+	if e < 3 || e&1 == 0 || e > _MASK {
+		return nil, errNoInverse
+	}
+	y, ok := modInverseWord(lambda.modWord(e), e)
+	if ok != 1 {
+		return nil, errNoInverse
 	}
-	out.limbs[0] = 1
-	out.montgomeryRepresentation(m)
-	scratch := &nat{make([]uint, size)}
-	for _, b := range e {
-		for j := 4; j >= 0; j -= 4 {
-			scratch.montgomeryMul(out, out, m)
-			out.montgomeryMul(scratch, scratch, m)
-			scratch.montgomeryMul(out, out, m)
-			out.montgomeryMul(scratch, scratch, m)
-
-			window := uint((b >> j) & 0b1111)
-			for i := 0; i < len(xs); i++ {
-				selectedX.assign(ctEq(window, uint(i+1)), xs[i])
+
+	// t = 1 + lambda * (e - y), which needs an extra limb
+	size := len(lambda.limbs)
+	t := new(nat).expand(size + 1)
+	k := e - y
+	var c uint
+	for i := 0; i < size; i++ {
+		hi, lo := bits.Mul(lambda.limbs[i], k)
+		lo, cc := bits.Add(lo, c, 0)
+		hi += cc
+		t.limbs[i] = lo & _MASK
+		c = (hi << 1) | (lo >> _W)
+	}
+	t.limbs[size] = c
+	t.addWord(1)
+
+	// Then we divide t by e, word by word, like in modWord. The result is smaller
+	// than lambda, so the quotient for the top limb of t is always 0.
+	out := new(nat).expand(size)
+	var r uint
+	for i := size; i >= 0; i-- {
+		var q uint
+		q, r = div(r>>1, (r<<_W)|t.limbs[i], e)
+		if i < size {
+			out.limbs[i] = q
+		}
+	}
+	t.clear()
+	return out, nil
+}
+
+// exp calculates out <- x^e modulo m
+//
+// The exponent, e, is presented as bytes in big endian order. It gets padded with
+// leading zeros to at least the size of m, so that the number of rounds only
+// depends on the size of m, and not on the length of e, which could otherwise
+// reveal the magnitude of a secret exponent. Public exponents should use expShort
+// or expVartime instead.
+//
+// x can have any size: it gets reduced modulo m first, with mod, which also gives it
+// the announced length of m, as montgomeryMul expects. The output will be expanded to
+// the correct size and overwritten.
+func (out *nat) exp(x *nat, e []byte, m *modulus) *nat {
+	xReduced := new(nat).mod(x, m)
+	padded := padExponent(e, m)
+	out.expWindow(xReduced, padded, expWindowSize(len(padded)*8), m)
+	clearPaddedExponent(padded, e)
+	xReduced.clear()
+	return out
+}
+
+// padExponent returns e with leading zeros added, so that it has at least as many bytes as m
+//
+// e is returned as is if it's already large enough.
+func padExponent(e []byte, m *modulus) []byte {
+	size := m.byteLen()
+	if len(e) >= size {
+		return e
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(e):], e)
+	return padded
+}
+
+// clearPaddedExponent overwrites the copy made by padExponent, if there was one
+//
+// The copy of a secret exponent is just as secret, but e itself belongs to the caller.
+func clearPaddedExponent(padded []byte, e []byte) {
+	if len(padded) != len(e) {
+		for i := range padded {
+			padded[i] = 0
+		}
+	}
+}
+
+// expLowMem calculates out <- x^e modulo m, like exp, but with windows of only w bits
+//
+// w should be 1 or 2, for memory constrained environments: the table of precomputed
+// values then holds 1 or 3 values, instead of the 15 values exp uses for large
+// exponents, at the cost of more multiplications. For a 4096 bit modulus, that's
+// 528 bytes with w = 1, instead of 7920 bytes. Like exp, e gets padded to the
+// size of m, so the work done doesn't depend on e.
+//
+// x can have any size, like with exp. The output will be expanded to the correct
+// size and overwritten.
+func (out *nat) expLowMem(x *nat, e []byte, w uint, m *modulus) *nat {
+	xReduced := new(nat).mod(x, m)
+	padded := padExponent(e, m)
+	out.expWindow(xReduced, padded, w, m)
+	clearPaddedExponent(padded, e)
+	xReduced.clear()
+	return out
+}
+
+// expNat calculates out <- x^e modulo m, like exp, with the exponent as a nat
+//
+// Rather than serializing e, this reads windows straight out of its limbs. The
+// exponent is treated as having at least as many limbs as m, so the number of
+// iterations only depends on the announced lengths of e and m, and not on how many
+// leading zeros e has. This suits secret exponents, like those used for CRT
+// decryption, which are naturally smaller than their modulus.
+//
+// x can have any size, like with exp. The output will be expanded to the correct
+// size and overwritten.
+func (out *nat) expNat(x *nat, e *nat, m *modulus) *nat {
+	size := len(m.nat.limbs)
+	if len(e.limbs) > size {
+		size = len(e.limbs)
+	}
+	bits := uint(size) * _W
+	w := expWindowSize(int(bits))
+	xReduced := new(nat).mod(x, m)
+	out.expWindows(xReduced, bits, w, func(i uint) uint { return limbWindowAt(e.limbs, i, w) }, m)
+	xReduced.clear()
+	return out
+}
+
+// expWindowSize chooses the window size for an exponent with a given number of bits
+//
+// Larger windows mean fewer multiplications per exponent bit, but require a table
+// of 2^w - 1 precomputed values, which only pays off for large enough exponents.
+// The size of the exponent is leaked anyways, so this choice leaks nothing more.
+//
+// For 2048 bit exponents, 4 and 5 bit windows perform about the same, so we stick
+// with the smaller table.
+func expWindowSize(bits int) uint {
+	switch {
+	case bits <= 32:
+		return 2
+	case bits <= 384:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// windowAt returns the w bits of e starting at bit i, counting from the least significant bit
+//
+// The exponent, e, is presented as bytes in big endian order. Bits past the end of e
+// are treated as zero.
+//
+// The positions being read are public, so this leaks nothing about e.
+func windowAt(e []byte, i uint, w uint) uint {
+	var window uint
+	for j := w; j > 0; j-- {
+		bit := i + j - 1
+		window <<= 1
+		if byteI := bit / 8; byteI < uint(len(e)) {
+			window |= uint(e[uint(len(e))-1-byteI]>>(bit%8)) & 1
+		}
+	}
+	return window
+}
+
+// limbWindowAt returns the w bits of e starting at bit i, like windowAt, with e given as limbs
+//
+// Limbs past the end of e are treated as zero. Like with windowAt, the positions
+// being read are public, so this leaks nothing about e.
+func limbWindowAt(e []uint, i uint, w uint) uint {
+	var window uint
+	for j := w; j > 0; j-- {
+		bit := i + j - 1
+		window <<= 1
+		if limbI := bit / _W; limbI < uint(len(e)) {
+			window |= (e[limbI] >> (bit % _W)) & 1
+		}
+	}
+	return window
+}
+
+// selectNat sets out = table[index], without leaking index
+//
+// Every entry of the table gets read and masked, so the memory access pattern only
+// depends on the size of the table. The entries must have the same announced length
+// as out. If index is out of range, out is set to 0.
+//
+// This costs about as much as the table is large, which is noticeable next to a
+// plain copy of table[index], but that copy would leak the index, i.e. bits of
+// a secret exponent, through which cache lines get accessed. TestSelectNatReadsAll
+// checks that every entry is read, whatever the index.
+func selectNat(out *nat, table []*nat, index uint) {
+	out.clear()
+	for i, entry := range table {
+		mask := -uint(ctEq(index, uint(i)))
+		for j := 0; j < len(out.limbs); j++ {
+			out.limbs[j] |= entry.limbs[j] & mask
+		}
+	}
+}
+
+// precomputeWindowTable sets table[i] = x^(i + 1), in montgomery representation
+//
+// This always does len(table) - 1 multiplications, and deliberately doesn't take
+// the exponent, so that the precomputation can't depend on it, as explained in expWindow.
+func precomputeWindowTable(table []*nat, x *nat, m *modulus) {
+	table[0].assign(1, x).montgomeryRepresentation(m)
+	for i := 1; i < len(table); i++ {
+		(*montyNat)(table[i]).mul((*montyNat)(table[i-1]), (*montyNat)(table[0]), m)
+	}
+}
+
+// expWindow calculates out <- x^e modulo m, using windows of w bits
+//
+// The exponent, e, is presented as bytes in big endian order. The window size
+// should be between 1 and 8, and uses a table of 2^w - 1 values.
+//
+// The whole table always gets computed, even if e is small, or never uses some of
+// its entries. This is what keeps secret exponents from leaking: the work done only
+// depends on w, and the length of e. Shrinking the table based on the bits of e
+// would be faster, but leak which windows appear in e.
+//
+// The output will be expanded to the correct size and overwritten.
+func (out *nat) expWindow(x *nat, e []byte, w uint, m *modulus) *nat {
+	return out.expWindows(x, uint(len(e))*8, w, func(i uint) uint { return windowAt(e, i, w) }, m)
+}
+
+// expWindows calculates out <- x^e modulo m, for an exponent of the given number of bits
+//
+// This is the loop shared by expWindow and expNat, which differ in how e is stored:
+// windowAt(i) must return the w bits of e starting at bit i, counting from the least
+// significant bit, with bits past the end of e being zero.
+func (out *nat) expWindows(x *nat, bits uint, w uint, windowAt func(i uint) uint, m *modulus) *nat {
+	s := getExpScratch(m, w)
+	defer putExpScratch(s)
+
+	table := s.table
+	precomputeWindowTable(table, x, m)
+
+	selectedX := s.selected
+	// We alternate between two buffers, since montgomeryMul can't work in place
+	acc := (*montyNat)(out.expandFor(m))
+	scratch := s.scratch
+	acc.set(m.r)
+	// The exponent gets padded with zeros to contain a whole number of windows
+	windows := (bits + w - 1) / w
+	for i := windows; i > 0; i-- {
+		for j := uint(0); j < w; j++ {
+			scratch.mul(acc, acc, m)
+			acc, scratch = scratch, acc
+		}
+
+		window := windowAt((i - 1) * w)
+		// A window of 0 wraps around to an index out of range, but we don't multiply then
+		selectNat((*nat)(selectedX), table, window-1)
+		scratch.mul(acc, selectedX, m)
+		acc.assign(1^ctEq(window, 0), scratch)
+	}
+	// acc might be out, or the pooled scratch buffer, and fromMonty handles either.
+	// Either way, putExpScratch clears the pooled buffer afterwards.
+	out.fromMonty(acc, m)
+	return out
+}
+
+// expLadder calculates out <- x^e modulo m, using a Montgomery ladder
+//
+// This produces the same result as exp, but does exactly one squaring and one
+// multiplication for every bit of e, always on the same buffers, with the bits
+// of e only selecting which values get swapped. This makes for a more regular
+// pattern of operations than the table lookups of expWindow, at the cost of
+// being slower, for environments where power analysis is a concern.
+//
+// Like exp, x can have any size, e gets padded to the size of m, and the output
+// will be expanded to the correct size and overwritten.
+func (out *nat) expLadder(x *nat, e []byte, m *modulus) *nat {
+	xReduced := new(nat).mod(x, m)
+	padded := padExponent(e, m)
+	// We maintain r1 = r0 * x, starting with r0 = 1, and r1 = x
+	r0 := newMontyNat(m).set(m.r)
+	r1 := xReduced.toMonty(m)
+	t0, t1 := newMontyNat(m), newMontyNat(m)
+	for i := len(padded) * 8; i > 0; i-- {
+		bit := choice(windowAt(padded, uint(i-1), 1))
+		// If the bit is set, we update r1 <- r1^2, and r0 <- r0 * r1 instead
+		(*nat)(r0).swap(bit, (*nat)(r1))
+		t1.mul(r0, r1, m)
+		t0.mul(r0, r0, m)
+		r0, t0 = t0, r0
+		r1, t1 = t1, r1
+		(*nat)(r0).swap(bit, (*nat)(r1))
+	}
+	out.fromMonty(r0, m)
+
+	r0.clear()
+	r1.clear()
+	t0.clear()
+	t1.clear()
+	clearPaddedExponent(padded, e)
+	xReduced.clear()
+	return out
+}
+
+// expShort calculates out <- x^e modulo m, for an exponent e fitting in a single word
+//
+// Unlike exp, this leaks the value of e, and should only be used with public exponents.
+// See expVartime.
+//
+// The output will be expanded to the correct size and overwritten.
+func (out *nat) expShort(x *nat, e uint, m *modulus) *nat {
+	var eBytes [8]byte
+	binary.BigEndian.PutUint64(eBytes[:], uint64(e))
+	return out.expVartime(x, eBytes[:], m)
+}
+
+// slidingWindowSize chooses the window size for expVartime, with an exponent of a given number of bits
+//
+// With w bit windows, the table needs 2^(w - 1) multiplications to fill, and each window
+// covers w bits on average, plus the run of zeros following it.
+func slidingWindowSize(bits int) uint {
+	switch {
+	case bits <= 32:
+		return 1
+	case bits <= 96:
+		return 3
+	case bits <= 384:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// expVartime calculates out <- x^e modulo m, using sliding windows
+//
+// The exponent, e, is presented as bytes in big endian order.
+//
+// Only odd powers of x go in the table, and runs of zero bits in the exponent are
+// skipped over with squarings alone, saving multiplications compared to exp. This
+// leaks the value of e, and should only be used with public exponents. Nothing
+// about the value of x is leaked.
+//
+// The output will be expanded to the correct size and overwritten.
+func (out *nat) expVartime(x *nat, e []byte, m *modulus) *nat {
+	var eBits int
+	for i, b := range e {
+		if b != 0 {
+			eBits = (len(e)-i-1)*8 + bits.Len8(b)
+			break
+		}
+	}
+	w := slidingWindowSize(eBits)
+	// When m leaves enough room, the products don't need to be reduced along the way,
+	// only once at the end. This only depends on the size of m.
+	lazy := m.lazyCompatible()
+	mul := func(out, x, y *montyNat) {
+		if lazy {
+			out.mulLazy(x, y, m)
+		} else {
+			out.mul(x, y, m)
+		}
+	}
+
+	// table[i] holds x^(2i + 1)
+	table := make([]*montyNat, 1<<(w-1))
+	table[0] = x.toMonty(m)
+	xSquared := newMontyNat(m)
+	if len(table) > 1 {
+		mul(xSquared, table[0], table[0])
+	}
+	for i := 1; i < len(table); i++ {
+		table[i] = newMontyNat(m)
+		mul(table[i], table[i-1], xSquared)
+	}
+
+	// We alternate between two buffers, since montgomeryMul can't work in place
+	acc := newMontyNat(m)
+	scratch := newMontyNat(m)
+	// Until the first window, acc holds 1, and there's no need to square it
+	started := false
+	for i := eBits - 1; i >= 0; {
+		if windowAt(e, uint(i), 1) == 0 {
+			mul(scratch, acc, acc)
+			acc, scratch = scratch, acc
+			i--
+			continue
+		}
+		// The window goes from bit i down to bit j, and is as long as possible, while ending with a 1
+		j := i - int(w) + 1
+		if j < 0 {
+			j = 0
+		}
+		for windowAt(e, uint(j), 1) == 0 {
+			j++
+		}
+		window := windowAt(e, uint(j), uint(i-j+1))
+		if started {
+			for k := j; k <= i; k++ {
+				mul(scratch, acc, acc)
+				acc, scratch = scratch, acc
 			}
-			scratch.montgomeryMul(out, selectedX, m)
-			out.assign(1^ctEq(window, 0), scratch)
+			mul(scratch, acc, table[window>>1])
+			acc, scratch = scratch, acc
+		} else {
+			acc.set(table[window>>1])
+			started = true
 		}
+		i = j - 1
+	}
+	if !started {
+		acc.set(m.r)
 	}
-	for i := 0; i < len(scratch.limbs); i++ {
-		scratch.limbs[i] = 0
+	(*nat)(acc).finalReduce(m)
+	out.fromMonty(acc, m)
+
+	acc.clear()
+	scratch.clear()
+	xSquared.clear()
+	for _, xi := range table {
+		xi.clear()
 	}
-	scratch.limbs[0] = 1
-	// By montgomery multiplying with 1, we convert back from montgomery representation
-	outC := out.clone()
-	out.montgomeryMul(outC, scratch, m)
 	return out
 }
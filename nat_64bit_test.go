@@ -0,0 +1,262 @@
+package ctrsa
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"testing"
+)
+
+// These examples are written out for 63 bit limbs, and only run on 64 bit platforms.
+// Their limbs go through limb64, which keeps the file compiling on 32 bit platforms.
+
+// skipUnless64Bit skips tests whose examples assume 63 bit limbs
+func skipUnless64Bit(t *testing.T) {
+	if bits.UintSize != 64 {
+		t.Skip("examples are written out for 63 bit limbs")
+	}
+}
+
+// limb64 converts x to a limb, truncating it on 32 bit platforms
+func limb64(x uint64) uint {
+	return uint(x)
+}
+
+// limbs64 converts xs to limbs, like limb64
+func limbs64(xs ...uint64) []uint {
+	limbs := make([]uint, len(xs))
+	for i, x := range xs {
+		limbs[i] = limb64(x)
+	}
+	return limbs
+}
+
+func TestFromBigExamples(t *testing.T) {
+	skipUnless64Bit(t)
+	// Two full 64 bit words, and a single bit above them
+	theBig, _ := new(big.Int).SetString("0x1_FFFF_FFFF_FFFF_FFFF_FFFF_FFFF_FFFF_FFFF", 0)
+	expected := &nat{limbs64(0x7FFF_FFFF_FFFF_FFFF, 0x7FFF_FFFF_FFFF_FFFF, 0b111)}
+	actual := natFromBig(theBig)
+	if actual.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", actual, expected)
+	}
+}
+
+func TestFillBytes(t *testing.T) {
+	skipUnless64Bit(t)
+	x := &nat{limbs64(0x7F22_3344_5566_7788, 1)}
+	xBytes := []byte{0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+	for l := 0; l <= len(xBytes); l++ {
+		actual := x.fillBytes(make([]byte, l))
+		expected := xBytes[len(xBytes)-l:]
+		if !bytes.Equal(actual, expected) {
+			t.Errorf("%+v != %+v", actual, expected)
+		}
+	}
+}
+
+func TestFillBytesPadding(t *testing.T) {
+	skipUnless64Bit(t)
+	x := &nat{limbs64(0x7F22_3344_5566_7788, 1)}
+	xBytes := []byte{0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+	for padding := 1; padding <= 16; padding++ {
+		out := make([]byte, len(xBytes)+padding)
+		// Make sure that stale bytes in the buffer get overwritten
+		for i := range out {
+			out[i] = 0xAA
+		}
+		actual := x.fillBytes(out)
+		expected := append(make([]byte, padding), xBytes...)
+		if !bytes.Equal(actual, expected) {
+			t.Errorf("%+v != %+v", actual, expected)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	skipUnless64Bit(t)
+	examples := []struct {
+		m        []uint
+		x        []uint
+		expected []byte
+	}{
+		// A top limb with 8 bits set
+		{limbs64(1, 0xFF), limbs64(0x7F22_3344_5566_7788, 0x12), []byte{0x09, 0x7F, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}},
+		// A top limb with a single bit set
+		{limbs64(1, 1), limbs64(0x0000_0000_0000_0001, 0), []byte{0, 0, 0, 0, 0, 0, 0, 0x01}},
+		// A top limb that's completely full
+		{limbs64(1, 0x7FFF_FFFF_FFFF_FFFF), limbs64(0x7FFF_FFFF_FFFF_FFFF, 0x4000_0000_0000_0000), []byte{0x20, 0, 0, 0, 0, 0, 0, 0, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+	for _, example := range examples {
+		m := modulusFromNat(&nat{example.m})
+		actual := (&nat{example.x}).bytes(m)
+		if !bytes.Equal(actual, example.expected) {
+			t.Errorf("%x != %x", actual, example.expected)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	skipUnless64Bit(t)
+	x := &nat{limbs64(0x7FFF_FFFF_FFFF_FFFF, 1, 0)}
+	expected := "18446744073709551615"
+	if actual := fmt.Sprintf("%+v", x); actual != expected {
+		t.Errorf("%s != %s", actual, expected)
+	}
+	if actual := (&nat{nil}).String(); actual != "0" {
+		t.Errorf("%s != 0", actual)
+	}
+	m := modulusFromNat(&nat{limbs64(13)})
+	if actual := fmt.Sprint(m); actual != "13" {
+		t.Errorf("%s != 13", actual)
+	}
+}
+
+func TestFromBytes(t *testing.T) {
+	skipUnless64Bit(t)
+	x := &nat{limbs64(0x7F22_3344_5566_7788, 1)}
+	xBytes := []byte{0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+	actual := natFromBytes(xBytes)
+	if actual.cmpEq(x) != 1 {
+		t.Errorf("%+v != %+v", actual, x)
+	}
+}
+
+func TestFromBytesChecked(t *testing.T) {
+	skipUnless64Bit(t)
+	m := modulusFromNat(&nat{limbs64(0x7F22_3344_5566_7788, 1)})
+	examples := []struct {
+		bytes []byte
+		ok    bool
+	}{
+		{[]byte{0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x87}, true},
+		{[]byte{0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}, false},
+		{[]byte{0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x89}, false},
+		{[]byte{0x01}, true},
+		{[]byte{}, true},
+		// Leading zeros are fine, even if they push us past the size of the modulus
+		{append(make([]byte, 16), 0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x87), true},
+		{append([]byte{0x01}, make([]byte, 16)...), false},
+	}
+	for _, example := range examples {
+		actual, err := natFromBytesChecked(example.bytes, m)
+		if (err == nil) != example.ok {
+			t.Errorf("%x: expected ok = %v, got %v", example.bytes, example.ok, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(actual.limbs) != len(m.nat.limbs) {
+			t.Errorf("%+v doesn't have the size of %+v", actual, m.nat)
+		}
+		expected := new(big.Int).SetBytes(example.bytes)
+		if new(big.Int).SetBytes(actual.fillBytes(make([]byte, 16))).Cmp(expected) != 0 {
+			t.Errorf("%+v != %v", actual, expected)
+		}
+	}
+}
+
+func TestMarshalExamples(t *testing.T) {
+	skipUnless64Bit(t)
+	x := &nat{limbs64(0x7F22_3344_5566_7788, 1, 0)}
+	expected := []byte{
+		0, 0, 0, 3,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88,
+	}
+	actual, err := x.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("%x != %x", actual, expected)
+	}
+}
+
+func TestDiv(t *testing.T) {
+	skipUnless64Bit(t)
+	hi, lo := limb64(0xFFFF), limb64(0xFFFF_FFFF_FFFF_AABB)
+	d := limb64(0xFFFF_FFFF_FFFF_FFFF)
+	expectedQ, expectedR := uint(0x10000), uint(0xAABB)
+	actualQ, actualR := div(hi, lo, d)
+	if actualQ != expectedQ {
+		t.Errorf("%+v != %+v", actualQ, expectedQ)
+	}
+	if actualR != expectedR {
+		t.Errorf("%+v != %+v", actualR, expectedR)
+	}
+}
+
+func TestModulusLen(t *testing.T) {
+	skipUnless64Bit(t)
+	examples := []struct {
+		limbs   []uint
+		bitLen  int
+		byteLen int
+	}{
+		{limbs64(1), 1, 1},
+		{limbs64(0xFF), 8, 1},
+		{limbs64(0x1FF), 9, 2},
+		{limbs64(0x7FFF_FFFF_FFFF_FFFF), 63, 8},
+		// A nearly empty top limb
+		{limbs64(1, 1), 64, 8},
+		{limbs64(1, 2), 65, 9},
+		// A nearly full top limb
+		{limbs64(1, 0x4000_0000_0000_0000), 126, 16},
+		{limbs64(1, 0x7FFF_FFFF_FFFF_FFFF), 126, 16},
+		{limbs64(1, 0, 1), 127, 16},
+		// Leading zero limbs get trimmed
+		{limbs64(1, 1, 0, 0), 64, 8},
+	}
+	for _, example := range examples {
+		m := modulusFromNat(&nat{example.limbs})
+		if actual := m.bitLen(); actual != example.bitLen {
+			t.Errorf("%+v.bitLen() = %d, expected %d", example.limbs, actual, example.bitLen)
+		}
+		if actual := m.byteLen(); actual != example.byteLen {
+			t.Errorf("%+v.byteLen() = %d, expected %d", example.limbs, actual, example.byteLen)
+		}
+	}
+}
+
+func TestShiftInExamples(t *testing.T) {
+	skipUnless64Bit(t)
+	m := modulusFromNat(&nat{limbs64(13)})
+	x := &nat{limbs64(0)}
+	x.shiftIn(limb64(0x7FFF_FFFF_FFFF_FFFF), m)
+	expected := &nat{limbs64(7)}
+	if x.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", x, expected)
+	}
+	x.shiftIn(limb64(0x7FFF_FFFF_FFFF_FFFF), m)
+	expected = &nat{limbs64(11)}
+	if x.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", x, expected)
+	}
+	m = modulusFromNat(&nat{limbs64(13, 13)})
+	x = &nat{limbs64(0, 0)}
+	x.shiftIn(limb64(0x7FFF_FFFF_FFFF_FFFF), m)
+	expected = &nat{limbs64(0x7FFF_FFFF_FFFF_FFFF, 0)}
+	if x.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", x, expected)
+	}
+	x.shiftIn(0, m)
+	expected = &nat{limbs64(0x8, 0x6)}
+	if x.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", x, expected)
+	}
+}
+
+func TestMod(t *testing.T) {
+	skipUnless64Bit(t)
+	m := modulusFromNat(&nat{limbs64(13, 13)})
+	x := &nat{limbs64(1, 1, 1)}
+	out := new(nat)
+	out.mod(x, m)
+	expected := &nat{limbs64(9, 8)}
+	if out.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", out, expected)
+	}
+}
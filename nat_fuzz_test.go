@@ -0,0 +1,39 @@
+//go:build go1.18
+// +build go1.18
+
+package ctrsa
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzBytesRoundtrip(f *testing.F) {
+	// Lengths around multiples of the limb size, where the shifts wrap around
+	for _, size := range []int{0, 1, 7, 8, 9, 15, 16, 17, 31, 32, 33, 63, 64, 65, 256} {
+		seed := make([]byte, size)
+		for i := range seed {
+			seed[i] = 0xFF
+		}
+		f.Add(seed)
+		if size > 0 {
+			seed = append([]byte{}, seed...)
+			seed[0] = 0
+			f.Add(seed)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		x := natFromBytes(data)
+		if actual := x.fillBytes(make([]byte, len(data))); !bytes.Equal(actual, data) {
+			t.Errorf("%x != %x", actual, data)
+		}
+		// Without the leading zeros, the shortest encoding should also round trip
+		trimmed := bytes.TrimLeft(data, "\x00")
+		if actual := x.fillBytes(make([]byte, len(trimmed))); !bytes.Equal(actual, trimmed) {
+			t.Errorf("%x != %x", actual, trimmed)
+		}
+		if actual := (x.bitLen() + 7) / 8; actual != len(trimmed) {
+			t.Errorf("%x: byte length %d != %d", data, actual, len(trimmed))
+		}
+	})
+}
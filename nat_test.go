@@ -2,9 +2,12 @@ package ctrsa
 
 import (
 	"bytes"
+	"fmt"
 	"math/big"
+	"math/bits"
 	"math/rand"
 	"reflect"
+	"sync"
 	"testing"
 	"testing/quick"
 )
@@ -12,7 +15,7 @@ import (
 func (*nat) Generate(r *rand.Rand, size int) reflect.Value {
 	limbs := make([]uint, size)
 	for i := 0; i < size; i++ {
-		limbs[i] = uint(r.Uint64()) & 0x7FFF_FFFF_FFFF_FFFE
+		limbs[i] = uint(r.Uint64()) & (_MASK &^ 1)
 	}
 	return reflect.ValueOf(&nat{limbs})
 }
@@ -20,7 +23,7 @@ func (*nat) Generate(r *rand.Rand, size int) reflect.Value {
 func testModAddCommutative(a *nat, b *nat) bool {
 	mLimbs := make([]uint, len(a.limbs))
 	for i := 0; i < len(mLimbs); i++ {
-		mLimbs[i] = 0x7FFF_FFFF_FFFF_FFFF
+		mLimbs[i] = _MASK
 	}
 	m := modulusFromNat(&nat{mLimbs})
 	aPlusB := a.clone()
@@ -40,7 +43,7 @@ func TestModAddCommutative(t *testing.T) {
 func testModSubThenAddIdentity(a *nat, b *nat) bool {
 	mLimbs := make([]uint, len(a.limbs))
 	for i := 0; i < len(mLimbs); i++ {
-		mLimbs[i] = 0x7FFF_FFFF_FFFF_FFFF
+		mLimbs[i] = _MASK
 	}
 	m := modulusFromNat(&nat{mLimbs})
 	original := a.clone()
@@ -56,6 +59,39 @@ func TestModSubThenAddIdentity(t *testing.T) {
 	}
 }
 
+func testSubCarryMatchesSub(a *nat, b *nat) bool {
+	expected := a.clone()
+	expectedC := expected.sub(1, b)
+	actual := a.clone()
+	actualC := actual.subCarry(b)
+	return actualC == expectedC && actual.cmpEq(expected) == 1
+}
+
+func TestSubCarryMatchesSub(t *testing.T) {
+	err := quick.Check(testSubCarryMatchesSub, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testAbsDiff(a *nat, b *nat) bool {
+	a, b = alignedClones(a, b)
+	expected := new(big.Int).Sub(a.toBig(), b.toBig())
+	expected.Abs(expected)
+	return a.absDiff(b).toBig().Cmp(expected) == 0
+}
+
+func TestAbsDiff(t *testing.T) {
+	err := quick.Check(testAbsDiff, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	x := &nat{[]uint{_MASK, 3}}
+	if x.absDiff(x.clone()).isZero() != 1 {
+		t.Errorf("|x - x| = %+v != 0", x)
+	}
+}
+
 func testMontgomeryRoundtrip(a *nat) bool {
 	one := &nat{make([]uint, len(a.limbs))}
 	one.limbs[0] = 1
@@ -76,85 +112,1020 @@ func TestMontgomeryRoundtrip(t *testing.T) {
 	}
 }
 
-func TestFromBigExamples(t *testing.T) {
-	theBig := new(big.Int).SetBits([]big.Word{0xFFFF_FFFF_FFFF_FFFF, 0xFFFF_FFFF_FFFF_FFFF, 0b1})
-	expected := &nat{[]uint{0x7FFF_FFFF_FFFF_FFFF, 0x7FFF_FFFF_FFFF_FFFF, 0b111}}
-	actual := natFromBig(theBig)
+func TestExpandClearsStaleLimbs(t *testing.T) {
+	x := &nat{[]uint{1, 2, 3}}
+	x.expand(1).expand(3)
+	expected := &nat{[]uint{1, 0, 0}}
+	if x.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", x, expected)
+	}
+}
+
+func TestExpandForReusedNat(t *testing.T) {
+	// Multi-prime decryption reduces a value modulo a large modulus, then reuses
+	// the same nat modulo a small prime, and expands it back to the large size.
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 20; i++ {
+		bigNat := (*nat)(nil).Generate(r, 4).Interface().(*nat)
+		bigNat.limbs[0] |= 1
+		bigNat.limbs[3] |= 1
+		large := modulusFromNat(bigNat)
+		small := modulusFromNat(&nat{[]uint{101}})
+
+		x := new(nat).mod((*nat)(nil).Generate(r, 4).Interface().(*nat), large)
+		x.mod((*nat)(nil).Generate(r, 4).Interface().(*nat), small)
+		expected := x.toBig()
+		x.expandFor(large)
+		if actual := x.toBig(); actual.Cmp(expected) != 0 || len(x.limbs) != 4 {
+			t.Errorf("%+v != %v", x, expected)
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	x := &nat{[]uint{1, _MASK, 0, 42}}
+	if x.clear() != x {
+		t.Errorf("clear should return its receiver")
+	}
+	expected := &nat{make([]uint, 4)}
+	if len(x.limbs) != len(expected.limbs) || x.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", x, expected)
+	}
+}
+
+func TestSetUint(t *testing.T) {
+	for _, v := range []uint64{0, 1, 1<<63 - 1, 1 << 63, 1<<63 | 1, 1<<64 - 1} {
+		x := new(nat).setUint(v)
+		if expected := new(big.Int).SetUint64(v); x.toBig().Cmp(expected) != 0 {
+			t.Errorf("%x: %+v != %v", v, x, expected)
+		}
+		if len(x.limbs) != (64+_W-1)/_W {
+			t.Errorf("%x: unexpected length %d", v, len(x.limbs))
+		}
+		for _, limb := range x.limbs {
+			if limb > _MASK {
+				t.Errorf("%x: limb %x isn't reduced", v, limb)
+			}
+		}
+	}
+	// Reusing a larger nat shouldn't leave anything behind
+	x := &nat{[]uint{1, 2, 3, 4}}
+	if x.setUint(5); x.toBig().Cmp(big.NewInt(5)) != 0 || len(x.limbs) != (64+_W-1)/_W {
+		t.Errorf("%+v != 5", x)
+	}
+}
+
+func TestSetBig(t *testing.T) {
+	b, _ := new(big.Int).SetString("ffffffffffffffff0000000000000001", 16)
+	x := (&nat{[]uint{1, 2, 3, 4, 5}}).setBig(b)
+	if x.toBig().Cmp(b) != 0 {
+		t.Errorf("%+v != %v", x, b)
+	}
+	if expected := natFromBig(b); x.cmpEq(expected) != 1 || len(x.limbs) != len(expected.limbs) {
+		t.Errorf("%+v != %+v", x, expected)
+	}
+}
+
+func TestIsZeroIsOne(t *testing.T) {
+	examples := []struct {
+		x      *nat
+		isZero choice
+		isOne  choice
+	}{
+		{&nat{nil}, 1, 0},
+		{&nat{[]uint{0}}, 1, 0},
+		{&nat{[]uint{0, 0, 0}}, 1, 0},
+		{&nat{[]uint{1}}, 0, 1},
+		{&nat{[]uint{1, 0, 0}}, 0, 1},
+		{&nat{[]uint{1, 1}}, 0, 0},
+		{&nat{[]uint{0, 1}}, 0, 0},
+		{&nat{[]uint{3}}, 0, 0},
+		{&nat{[]uint{_MASK}}, 0, 0},
+	}
+	for _, example := range examples {
+		if actual := example.x.isZero(); actual != example.isZero {
+			t.Errorf("%+v.isZero() = %d", example.x, actual)
+		}
+		if actual := example.x.isOne(); actual != example.isOne {
+			t.Errorf("%+v.isOne() = %d", example.x, actual)
+		}
+	}
+}
+
+func TestFromBytesExactLimbs(t *testing.T) {
+	// _W bytes fill exactly 8 limbs
+	xBytes := bytes.Repeat([]byte{0xFF}, _W)
+	actual := natFromBytes(xBytes)
+	expected := &nat{make([]uint, 8)}
+	for i := range expected.limbs {
+		expected.limbs[i] = _MASK
+	}
+	if len(actual.limbs) != len(expected.limbs) || actual.cmpEq(expected) != 1 {
+		t.Errorf("%+v != %+v", actual, expected)
+	}
+	if roundTrip := actual.fillBytes(make([]byte, _W)); !bytes.Equal(roundTrip, xBytes) {
+		t.Errorf("%x != %x", roundTrip, xBytes)
+	}
+}
+
+func TestCmpLt(t *testing.T) {
+	examples := []struct {
+		x, y []uint
+		lt   choice
+	}{
+		{[]uint{0}, []uint{0}, 0},
+		{[]uint{3}, []uint{3}, 0},
+		{[]uint{3, 1}, []uint{3, 1}, 0},
+		{[]uint{2}, []uint{3}, 1},
+		{[]uint{3}, []uint{2}, 0},
+		{[]uint{_MASK, 0}, []uint{0, 1}, 1},
+		{[]uint{0, 1}, []uint{_MASK, 0}, 0},
+	}
+	for _, example := range examples {
+		x, y := &nat{example.x}, &nat{example.y}
+		if actual := x.cmpLt(y); actual != example.lt {
+			t.Errorf("%+v < %+v: %d", x, y, actual)
+		}
+		if actual := x.cmpLt(y) ^ x.cmpGeq(y); actual != 1 {
+			t.Errorf("%+v: cmpLt and cmpGeq agree", x)
+		}
+	}
+}
+
+func TestAddSubWord(t *testing.T) {
+	examples := []struct {
+		x     []uint
+		y     uint
+		sum   []uint
+		carry uint
+	}{
+		{[]uint{0}, 0, []uint{0}, 0},
+		{[]uint{5, 1}, 2, []uint{7, 1}, 0},
+		// The carry goes through every full limb
+		{[]uint{_MASK, _MASK, 0}, 2, []uint{1, 0, 1}, 0},
+		{[]uint{_MASK - 1, _MASK}, 2, []uint{0, 0}, 1},
+		{[]uint{_MASK, 3}, _MASK, []uint{_MASK - 1, 4}, 0},
+	}
+	for _, example := range examples {
+		x := &nat{append([]uint{}, example.x...)}
+		if carry := x.addWord(example.y); carry != example.carry || x.cmpEq(&nat{example.sum}) != 1 {
+			t.Errorf("%+v + %d = %+v, carry %d", example.x, example.y, x, carry)
+		}
+		// Subtracting undoes the addition, with the carry turning into a borrow
+		if borrow := x.subWord(example.y); borrow != example.carry || x.cmpEq(&nat{example.x}) != 1 {
+			t.Errorf("%+v - %d = %+v, borrow %d", example.sum, example.y, x, borrow)
+		}
+	}
+}
+
+func TestRandNatBelow(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		mNat := (*nat)(nil).Generate(r, 1+r.Intn(4)).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[len(mNat.limbs)-1] |= 1
+		m := modulusFromNat(mNat)
+		x, err := randNatBelow(r, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(x.limbs) != len(m.nat.limbs) || x.cmpGeq(m.nat) == 1 {
+			t.Errorf("%+v isn't reduced modulo %+v", x, m.nat)
+		}
+	}
+
+	// With 13 buckets, and 1000 expected draws in each, being off by 200 is very unlikely
+	m := modulusFromNat(&nat{[]uint{13}})
+	counts := make([]int, 13)
+	for i := 0; i < 13*1000; i++ {
+		x, err := randNatBelow(r, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[x.limbs[0]]++
+	}
+	for value, count := range counts {
+		if count < 800 || count > 1200 {
+			t.Errorf("%d was drawn %d times out of %d", value, count, 13*1000)
+		}
+	}
+
+	if _, err := randNatBelow(bytes.NewReader(nil), m); err == nil {
+		t.Errorf("randNatBelow succeeded without any randomness")
+	}
+}
+
+func TestShiftRight(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		x := (*nat)(nil).Generate(r, 1+r.Intn(8)).Interface().(*nat)
+		n := uint(r.Intn(len(x.limbs)*_W + 10))
+		expected := new(big.Int).Rsh(x.toBig(), n)
+		actual := x.clone().shiftRight(n)
+		if len(actual.limbs) != len(x.limbs) {
+			t.Errorf("shiftRight changed the size of %+v", x)
+		}
+		if actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("%+v >> %d: %+v != %v", x, n, actual, expected)
+		}
+	}
+}
+
+func TestBit(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		x := (*nat)(nil).Generate(r, 1+r.Intn(4)).Interface().(*nat)
+		xBig := x.toBig()
+		// Also check a few indices past the end of x
+		for j := uint(0); j < uint(len(x.limbs)*_W+70); j++ {
+			if actual, expected := x.bit(j), xBig.Bit(int(j)); actual != expected {
+				t.Errorf("bit %d of %+v: %d != %d", j, x, actual, expected)
+			}
+		}
+	}
+}
+
+func TestTrailingZeros(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 200; i++ {
+		x := (*nat)(nil).Generate(r, 1+r.Intn(4)).Interface().(*nat)
+		// Clear a random number of low bits, possibly spanning several limbs
+		x = x.shiftRight(uint(r.Intn(len(x.limbs) * _W))).shiftLeft(uint(r.Intn(3 * _W)))
+		if actual, expected := x.trailingZeros(), x.toBig().TrailingZeroBits(); actual != expected {
+			t.Errorf("trailing zeros of %+v: %d != %d", x, actual, expected)
+		}
+	}
+	if actual := (&nat{[]uint{0, 0}}).trailingZeros(); actual != 0 {
+		t.Errorf("trailing zeros of 0: %d != 0", actual)
+	}
+}
+
+func TestBitLen(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 200; i++ {
+		x := (*nat)(nil).Generate(r, 1+r.Intn(4)).Interface().(*nat)
+		// Clear a random number of high bits, possibly spanning several limbs
+		x.shiftRight(uint(r.Intn(len(x.limbs) * _W)))
+		if actual, expected := x.bitLen(), x.toBig().BitLen(); actual != expected {
+			t.Errorf("bit length of %+v: %d != %d", x, actual, expected)
+		}
+	}
+	for _, x := range []*nat{{nil}, {[]uint{0}}, {[]uint{0, 0, 0}}} {
+		if actual := x.bitLen(); actual != 0 {
+			t.Errorf("bit length of %+v: %d != 0", x, actual)
+		}
+	}
+}
+
+func testShiftLeft(a *nat, n uint8) bool {
+	// Go up to a few limbs past the size of a
+	shift := uint(n) % (4 * _W)
+	expected := new(big.Int).Lsh(a.toBig(), shift)
+	actual := a.clone().shiftLeft(shift)
+	return actual.toBig().Cmp(expected) == 0
+}
+
+func TestShiftLeft(t *testing.T) {
+	err := quick.Check(testShiftLeft, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShiftLeftExamples(t *testing.T) {
+	x := &nat{[]uint{_MASK, 0x1}}
+	for _, n := range []uint{0, 1, 62, 63, 64, 126, 127, 200} {
+		expected := new(big.Int).Lsh(x.toBig(), n)
+		actual := x.clone().shiftLeft(n)
+		if actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("%+v << %d: %+v != %v", x, n, actual, expected)
+		}
+		if expectedSize := len(x.limbs) + int((n+_W-1)/_W); len(actual.limbs) != expectedSize {
+			t.Errorf("%+v << %d: %d limbs instead of %d", x, n, len(actual.limbs), expectedSize)
+		}
+	}
+}
+
+func TestGCD(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	check := func(a, b *nat) {
+		expected := new(big.Int).GCD(nil, nil, a.toBig(), b.toBig())
+		actual := gcd(a, b)
+		if actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("gcd(%+v, %+v): %+v != %v", a, b, actual, expected)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		a := (*nat)(nil).Generate(r, 1+r.Intn(3)).Interface().(*nat)
+		b := (*nat)(nil).Generate(r, 1+r.Intn(3)).Interface().(*nat)
+		// Make sure we also have large common factors
+		c := (*nat)(nil).Generate(r, 1).Interface().(*nat)
+		check(a, b)
+		check(a, a)
+		check(&nat{[]uint{c.limbs[0] << 5 & _MASK, 0}}, &nat{[]uint{c.limbs[0] << 3 & _MASK}})
+	}
+	check(&nat{[]uint{0}}, &nat{[]uint{0}})
+	check(&nat{[]uint{0}}, &nat{[]uint{12}})
+	check(&nat{[]uint{65537}}, &nat{[]uint{0}})
+	check(&nat{[]uint{1 << (_W - 23)}}, &nat{[]uint{1 << (_W - 1), 1 << 10}})
+}
+
+func TestModInverse(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	check := func(x *nat, m *modulus) {
+		expected := new(big.Int).ModInverse(x.toBig(), m.nat.toBig())
+		actual, ok := new(nat).modInverse(x, m)
+		if (ok == 1) != (expected != nil) {
+			t.Errorf("inverse of %+v mod %+v: ok = %d", x, m.nat, ok)
+			return
+		}
+		if ok == 1 && actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("inverse of %+v mod %+v: %+v != %v", x, m.nat, actual, expected)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		mNat := (*nat)(nil).Generate(r, 1+r.Intn(4)).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[len(mNat.limbs)-1] |= 1
+		// Moduli with a full top limb need an extra bit when halving
+		if i%2 == 0 {
+			mNat.limbs[len(mNat.limbs)-1] |= 1 << (_W - 1)
+		}
+		m := modulusFromNat(mNat)
+		x := (*nat)(nil).Generate(r, len(m.nat.limbs)).Interface().(*nat)
+		check(new(nat).mod(x, m), m)
+		check(new(nat).expandFor(m), m)
+	}
+	// 3 * 5 has no inverse modulo 15 * 7
+	check(&nat{[]uint{15}}, modulusFromNat(&nat{[]uint{105}}))
+	check(&nat{[]uint{1}}, modulusFromNat(&nat{[]uint{105}}))
+}
+
+func TestModInverseEven(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	check := func(e uint, lambda *nat) {
+		expected := new(big.Int).ModInverse(new(big.Int).SetUint64(uint64(e)), lambda.toBig())
+		actual, err := modInverseEven(e, lambda)
+		if (err == nil) != (expected != nil) {
+			t.Errorf("inverse of %d mod %+v: err = %v", e, lambda, err)
+			return
+		}
+		if err == nil && (len(actual.limbs) != len(lambda.limbs) || actual.toBig().Cmp(expected) != 0) {
+			t.Errorf("inverse of %d mod %+v: %+v != %v", e, lambda, actual, expected)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		lambda := (*nat)(nil).Generate(r, 1+r.Intn(4)).Interface().(*nat)
+		lambda.limbs[0] &^= 1
+		lambda.limbs[len(lambda.limbs)-1] |= 1
+		for _, e := range []uint{3, 5, 17, 65537, uint(r.Int63n(1<<31)) | 1, _MASK} {
+			check(e, lambda)
+		}
+	}
+	// 3 divides 6, and even exponents are never invertible modulo an even number
+	check(3, &nat{[]uint{6}})
+	check(4, &nat{[]uint{10}})
+	// Odd moduli work too
+	check(65537, &nat{[]uint{1000001}})
+	if _, err := modInverseEven(1, &nat{[]uint{10}}); err == nil {
+		t.Errorf("expected an error for e = 1")
+	}
+}
+
+func testMul(a *nat, b *nat) bool {
+	expected := new(big.Int).Mul(a.toBig(), b.toBig())
+	actual := new(nat).mul(a, b)
+	return len(actual.limbs) == len(a.limbs)+len(b.limbs) && actual.toBig().Cmp(expected) == 0
+}
+
+func TestMul(t *testing.T) {
+	err := quick.Check(testMul, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMulExamples(t *testing.T) {
+	x := &nat{[]uint{_MASK, _MASK}}
+	y := &nat{[]uint{_MASK}}
+	// (b^2 - 1)(b - 1) = (b - 2)b^2 + (b - 1)b + 1
+	expected := &nat{[]uint{1, _MASK, _MASK - 1}}
+	actual := new(nat).mul(x, y)
 	if actual.cmpEq(expected) != 1 {
 		t.Errorf("%+v != %+v", actual, expected)
 	}
+	if low := new(nat).mulLow(x, y, 2); low.cmpEq(&nat{expected.limbs[:2]}) != 1 {
+		t.Errorf("%+v != %+v", low, expected.limbs[:2])
+	}
+}
+
+func testMarshalRoundtrip(a *nat) bool {
+	data, err := a.MarshalBinary()
+	if err != nil {
+		return false
+	}
+	b := new(nat)
+	if err := b.UnmarshalBinary(data); err != nil {
+		return false
+	}
+	return len(a.limbs) == len(b.limbs) && a.cmpEq(b) == 1
+}
+
+func TestMarshalRoundtrip(t *testing.T) {
+	err := quick.Check(testMarshalRoundtrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	examples := [][]byte{
+		nil,
+		{0, 0, 1},
+		// Too few bytes for a single limb
+		{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0},
+		// Too many bytes for a single limb
+		{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		// A value that doesn't fit in a single limb
+		{0, 0, 0, 1, 0x80, 0, 0, 0, 0, 0, 0, 0},
+	}
+	for _, data := range examples {
+		if err := new(nat).UnmarshalBinary(data); err == nil {
+			t.Errorf("%x: expected an error", data)
+		}
+	}
+}
+
+func TestCtCopyBytes(t *testing.T) {
+	src := []byte{0x00, 0x01, 0x80, 0xFF}
+	dst := []byte{0xFF, 0x7F, 0x00, 0x01}
+	original := append([]byte{}, dst...)
+	ctCopyBytes(0, dst, src)
+	if !bytes.Equal(dst, original) {
+		t.Errorf("on = 0: %x != %x", dst, original)
+	}
+	ctCopyBytes(1, dst, src)
+	if !bytes.Equal(dst, src) {
+		t.Errorf("on = 1: %x != %x", dst, src)
+	}
+	ctCopyBytes(1, nil, nil)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for different lengths")
+		}
+	}()
+	ctCopyBytes(1, dst, src[:3])
+}
+
+func TestCtBytesEq(t *testing.T) {
+	examples := []struct {
+		a        []byte
+		b        []byte
+		expected choice
+	}{
+		{nil, nil, 1},
+		{nil, []byte{}, 1},
+		{[]byte{1, 2, 3}, []byte{1, 2, 3}, 1},
+		{[]byte{1, 2, 3}, []byte{1, 2, 4}, 0},
+		{[]byte{0, 2, 3}, []byte{1, 2, 3}, 0},
+		{[]byte{0x80}, []byte{0x00}, 0},
+		{[]byte{0xFF}, []byte{0xFF}, 1},
+		// Differing lengths, even with a matching prefix
+		{[]byte{1, 2, 3}, []byte{1, 2}, 0},
+		{[]byte{}, []byte{0}, 0},
+	}
+	for _, example := range examples {
+		if actual := ctBytesEq(example.a, example.b); actual != example.expected {
+			t.Errorf("ctBytesEq(%x, %x) = %d", example.a, example.b, actual)
+		}
+		if actual := ctBytesEq(example.b, example.a); actual != example.expected {
+			t.Errorf("ctBytesEq(%x, %x) = %d", example.b, example.a, actual)
+		}
+	}
+}
+
+func TestDivByZero(t *testing.T) {
+	examples := [][2]uint{{0, 0}, {0, 5}, {3, 5}, {_MASK, ^uint(0)}}
+	for _, example := range examples {
+		hi, lo := example[0], example[1]
+		quo, rem := div(hi, lo, 0)
+		if quo != ^uint(0) || rem != lo {
+			t.Errorf("div(%x, %x, 0) = %x, %x", hi, lo, quo, rem)
+		}
+	}
 }
 
-func TestFillBytes(t *testing.T) {
-	x := &nat{[]uint{0x7F22_3344_5566_7788, 1}}
-	xBytes := []byte{0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
-	for l := 0; l <= len(xBytes); l++ {
-		actual := x.fillBytes(make([]byte, l))
-		expected := xBytes[len(xBytes)-l:]
-		if !bytes.Equal(actual, expected) {
+func TestModWord(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	divisors := []uint{1, 2, 3, 7, 65537, 1<<31 - 1, _MASK, ^uint(0)}
+	for i := 0; i < 100; i++ {
+		x := (*nat)(nil).Generate(r, r.Intn(8)).Interface().(*nat)
+		for _, d := range append(divisors, uint(r.Uint64())|1) {
+			expected := new(big.Int).Mod(x.toBig(), new(big.Int).SetUint64(uint64(d)))
+			if actual := x.modWord(d); uint64(actual) != expected.Uint64() {
+				t.Errorf("%+v mod %d: %d != %v", x, d, actual, expected)
+			}
+		}
+	}
+}
+
+func TestShiftInEstimateEdgeCases(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	// top returns the top _W bits of x, once shifted to line up with m, like in shiftIn
+	top := func(x *nat, m *modulus) uint {
+		size := len(m.nat.limbs)
+		return ((x.limbs[size-1] << m.leading) | (x.limbs[size-2] >> (_W - m.leading))) & _MASK
+	}
+	var hitOverflow, hitZero int
+	check := func(x *nat, y uint, m *modulus) {
+		a1, b0 := top(x, m), top(m.nat, m)
+		if a1 == b0 {
+			hitOverflow++
+		}
+		if a1 == 0 {
+			hitZero++
+		}
+		expected := new(big.Int).Lsh(x.toBig(), _W)
+		expected.Add(expected, new(big.Int).SetUint64(uint64(y)))
+		expected.Mod(expected, m.nat.toBig())
+		if actual := x.clone().shiftIn(y, m); actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("%+v:%x mod %+v: %+v != %v", x, y, m.nat, actual, expected)
+		}
+	}
+	for i := 0; i < 500; i++ {
+		size := 2 + r.Intn(3)
+		mNat := (*nat)(nil).Generate(r, size).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		// Adversarial moduli: full top limbs, tiny top limbs, and all ones below the top
+		switch i % 4 {
+		case 0:
+			mNat.limbs[size-1] = _MASK
+		case 1:
+			mNat.limbs[size-1] = 1
+		case 2:
+			for j := 0; j < size-1; j++ {
+				mNat.limbs[j] = _MASK
+			}
+		}
+		mNat.limbs[size-1] |= 1
+		m := modulusFromNat(mNat)
+		ys := []uint{0, _MASK, uint(r.Uint64()) & _MASK}
+
+		// x = m - d, for a small d, shares its top bits with m, so a1 == b0
+		for _, d := range []uint{1, 2, uint(r.Intn(1000)) + 1} {
+			x := m.nat.clone()
+			x.subWord(d)
+			for _, y := range ys {
+				check(x, y, m)
+			}
+		}
+		// Small values of x make the quotient estimate 0
+		for _, v := range []uint{0, 1, uint(r.Uint64()) & _MASK} {
+			x := new(nat).expandFor(m)
+			x.limbs[0] = v
+			for _, y := range ys {
+				check(x, y, m)
+			}
+		}
+	}
+	if hitOverflow == 0 || hitZero == 0 {
+		t.Errorf("didn't hit the edge cases: %d overflows, %d zeros", hitOverflow, hitZero)
+	}
+}
+
+func TestModulusFromNatCopies(t *testing.T) {
+	x := &nat{[]uint{13, 13, 0}}
+	m := modulusFromNat(x)
+	if len(x.limbs) != 3 {
+		t.Errorf("input got trimmed to %d limbs", len(x.limbs))
+	}
+	x.clear()
+	if m.nat.cmpEq(&nat{[]uint{13, 13}}) != 1 {
+		t.Errorf("clearing the input changed the modulus to %+v", m.nat)
+	}
+}
+
+func TestModulusFromProduct(t *testing.T) {
+	p, q := natFromBig(test2048Key.Primes[0]), natFromBig(test2048Key.Primes[1])
+	pCopy, qCopy := p.clone(), q.clone()
+	m := modulusFromProduct(p, q)
+	if m.nat.toBig().Cmp(test2048Key.N) != 0 {
+		t.Errorf("%+v != %v", m.nat, test2048Key.N)
+	}
+	if p.cmpEq(pCopy) != 1 || q.cmpEq(qCopy) != 1 {
+		t.Errorf("the factors were modified")
+	}
+	// The constants should match those of a modulus created the usual way
+	expected := modulusFromNat(natFromBig(test2048Key.N))
+	if m.equal(expected) != 1 || m.leading != expected.leading || m.m0inv != expected.m0inv || m.rr.cmpEq(expected.rr) != 1 {
+		t.Errorf("%+v doesn't match %+v", m, expected)
+	}
+}
+
+func TestModulusConcurrentUse(t *testing.T) {
+	m, err := modulusFromBytes(test2048Key.N.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := *m
+	snapshot.nat, snapshot.rr = m.nat.clone(), m.rr.clone()
+	snapshot.r = (*montyNat)((*nat)(m.r).clone())
+
+	r := rand.New(rand.NewSource(0))
+	const workers = 8
+	xs := make([]*nat, workers)
+	expected := make([]*nat, workers)
+	e := make([]byte, m.byteLen())
+	r.Read(e)
+	for i := range xs {
+		xs[i] = new(nat).mod((*nat)(nil).Generate(r, len(m.nat.limbs)).Interface().(*nat), m)
+		expected[i] = new(nat).exp(xs[i], e, m)
+		expected[i].modMul(xs[i], m)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				actual := new(nat).exp(xs[i], e, m)
+				actual.modMul(xs[i], m)
+				if actual.cmpEq(expected[i]) != 1 {
+					t.Errorf("worker %d: %+v != %+v", i, actual, expected[i])
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if m.nat.cmpEq(snapshot.nat) != 1 || m.rr.cmpEq(snapshot.rr) != 1 || (*nat)(m.r).cmpEq((*nat)(snapshot.r)) != 1 ||
+		m.leading != snapshot.leading || m.m0inv != snapshot.m0inv {
+		t.Errorf("modulus changed after being used")
+	}
+}
+
+func TestModulusFromBytes(t *testing.T) {
+	nBytes := test2048Key.N.Bytes()
+	if len(nBytes) != 256 {
+		t.Fatalf("test modulus has %d bytes", len(nBytes))
+	}
+	for _, b := range [][]byte{nBytes, append([]byte{0, 0, 0}, nBytes...)} {
+		m, err := modulusFromBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.bitLen() != test2048Key.N.BitLen() || m.nat.toBig().Cmp(test2048Key.N) != 0 {
+			t.Errorf("%x: wrong modulus %+v", b, m)
+		}
+	}
+	even := append([]byte{}, nBytes...)
+	even[len(even)-1] &^= 1
+	if _, err := modulusFromBytes(even); err != errModulusEven {
+		t.Errorf("expected errModulusEven, got %v", err)
+	}
+	for _, b := range [][]byte{nil, {0}, make([]byte, 256)} {
+		if _, err := modulusFromBytes(b); err != errModulusZero {
+			t.Errorf("%x: expected errModulusZero, got %v", b, err)
+		}
+	}
+}
+
+func TestNatBuilder(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, size := range []int{0, 1, 7, 8, 9, 64, 255, 256, 257} {
+		data := make([]byte, size)
+		r.Read(data)
+		if size > 2 {
+			// Leading zeros still count towards the announced length
+			data[0] = 0
+		}
+		expected := natFromBytes(data)
+		var b natBuilder
+		for _, chunk := range []int{1, 2, 3, 7, 8, 100, size + 1} {
+			for i := 0; i < size; i += chunk {
+				end := i + chunk
+				if end > size {
+					end = size
+				}
+				if n, err := b.Write(data[i:end]); n != end-i || err != nil {
+					t.Fatalf("Write returned %d, %v", n, err)
+				}
+			}
+			if actual := b.finalize(); actual.cmpEq(expected) != 1 || len(actual.limbs) != len(expected.limbs) {
+				t.Errorf("%d bytes, in chunks of %d: %+v != %+v", size, chunk, actual, expected)
+			}
+		}
+	}
+
+	var b natBuilder
+	nBytes := test2048Key.N.Bytes()
+	b.Write(nBytes[:100])
+	b.Write(nBytes[100:])
+	m, err := b.finalizeModulus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := modulusFromBytes(nBytes); m.equal(expected) != 1 {
+		t.Errorf("%+v != %+v", m, expected)
+	}
+	if _, err := b.finalizeModulus(); err != errModulusZero {
+		t.Errorf("empty builder: expected errModulusZero, got %v", err)
+	}
+}
+
+func TestSetBytes(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, size := range []int{1, 2, 3, 8} {
+		limbs := make([]uint, size)
+		for i := range limbs {
+			limbs[i] = uint(r.Uint64()) & _MASK
+		}
+		limbs[0] |= 1
+		limbs[size-1] |= 1
+		m := modulusFromNat(&nat{limbs})
+		x := new(nat)
+		for _, n := range []int{0, 1, 7, 8, 9, size * _W / 8, size*_W/8 + 1, 3 * size * 8} {
+			b := make([]byte, n)
+			r.Read(b)
+			expected := new(nat).mod(natFromBytes(b), m)
+			if x.setBytes(b, m); x.cmpEq(expected) != 1 || len(x.limbs) != size {
+				t.Errorf("%d limbs, %x: %+v != %+v", size, b, x, expected)
+			}
+		}
+	}
+}
+
+func TestSetBytesReusesLimbs(t *testing.T) {
+	m := makeBenchmarkModulus()
+	x := new(nat).expandFor(m)
+	backing := &x.limbs[0]
+	x.setBytes(make([]byte, 300), m)
+	if &x.limbs[0] != backing {
+		t.Errorf("setBytes allocated new limbs")
+	}
+}
+
+func TestModulusFromNatChecked(t *testing.T) {
+	if _, err := modulusFromNatChecked(&nat{[]uint{13, 1}}); err != nil {
+		t.Errorf("rejected an odd modulus: %s", err)
+	}
+	// Montgomery multiplication doesn't work with even moduli
+	for _, limbs := range [][]uint{{12}, {_MASK &^ 1, 1}} {
+		if _, err := modulusFromNatChecked(&nat{limbs}); err != errModulusEven {
+			t.Errorf("%+v: expected errModulusEven, got %v", limbs, err)
+		}
+	}
+	// These used to panic, rather than returning an error
+	for _, limbs := range [][]uint{nil, {0}, {0, 0}} {
+		if _, err := modulusFromNatChecked(&nat{limbs}); err != errModulusZero {
+			t.Errorf("%+v: expected errModulusZero, got %v", limbs, err)
+		}
+	}
+}
+
+func TestModulusCompare(t *testing.T) {
+	examples := []struct {
+		x     []uint
+		y     []uint
+		equal choice
+		less  choice
+	}{
+		{[]uint{13}, []uint{13}, 1, 0},
+		{[]uint{13}, []uint{15}, 0, 1},
+		{[]uint{15}, []uint{13}, 0, 0},
+		{[]uint{13}, []uint{13, 1}, 0, 1},
+		{[]uint{1, 1}, []uint{13}, 0, 0},
+		{[]uint{1, _MASK}, []uint{3, _MASK}, 0, 1},
+	}
+	for _, example := range examples {
+		x := modulusFromNat(&nat{example.x})
+		y := modulusFromNat(&nat{example.y})
+		// The same values, with a differing number of limbs, should compare in the same way
+		yPadded := modulusFromNat(&nat{example.y})
+		yPadded.nat.expand(len(example.y) + 2)
+		for _, other := range []*modulus{y, yPadded} {
+			if actual := x.equal(other); actual != example.equal {
+				t.Errorf("%+v.equal(%+v) = %d", x, other, actual)
+			}
+			if actual := x.less(other); actual != example.less {
+				t.Errorf("%+v.less(%+v) = %d", x, other, actual)
+			}
+		}
+	}
+}
+
+func TestModulusMontgomeryConstants(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		mNat := (*nat)(nil).Generate(r, 1+r.Intn(8)).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[len(mNat.limbs)-1] |= 1
+		m := modulusFromNat(mNat)
+
+		R := new(big.Int).Lsh(big.NewInt(1), uint(len(m.nat.limbs)*_W))
+		expectedR := new(big.Int).Mod(R, m.nat.toBig())
+		if (*nat)(m.r).toBig().Cmp(expectedR) != 0 {
+			t.Errorf("R mod %+v: %+v != %v", m.nat, m.r, expectedR)
+		}
+		expectedRR := new(big.Int).Mod(new(big.Int).Mul(R, R), m.nat.toBig())
+		if m.rr.toBig().Cmp(expectedRR) != 0 {
+			t.Errorf("R^2 mod %+v: %+v != %v", m.nat, m.rr, expectedRR)
+		}
+	}
+}
+
+func TestMontyNat(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		mNat := (*nat)(nil).Generate(r, 1+r.Intn(8)).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[len(mNat.limbs)-1] |= 1
+		m := modulusFromNat(mNat)
+		size := len(m.nat.limbs)
+		x := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+		y := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+
+		if roundtrip := new(nat).fromMonty(x.toMonty(m), m); roundtrip.cmpEq(x) != 1 {
+			t.Errorf("%+v != %+v", roundtrip, x)
+		}
+		expected := x.clone().modMul(y, m)
+		product := newMontyNat(m).mul(x.toMonty(m), y.toMonty(m), m)
+		if actual := new(nat).fromMonty(product, m); actual.cmpEq(expected) != 1 {
+			t.Errorf("%+v != %+v", actual, expected)
+		}
+		if actual := new(nat).mulMonty(x.toMonty(m), y, m); actual.cmpEq(expected) != 1 {
 			t.Errorf("%+v != %+v", actual, expected)
 		}
 	}
 }
 
-func TestFromBytes(t *testing.T) {
-	x := &nat{[]uint{0x7F22_3344_5566_7788, 1}}
-	xBytes := []byte{0xFF, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
-	actual := natFromBytes(xBytes)
-	if actual.cmpEq(x) != 1 {
-		t.Errorf("%+v != %+v", actual, x)
+func TestMontyNatEqual(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		mNat := (*nat)(nil).Generate(r, 1+r.Intn(8)).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[len(mNat.limbs)-1] |= 1
+		m := modulusFromNat(mNat)
+		size := len(m.nat.limbs)
+		x := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+		y := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+		xMonty, yMonty := x.toMonty(m), y.toMonty(m)
+
+		if xMonty.equal(x.toMonty(m)) != 1 {
+			t.Errorf("%+v: not equal to itself", xMonty)
+		}
+		if expected := x.cmpEq(y); xMonty.equal(yMonty) != expected {
+			t.Errorf("%+v, %+v: equal != %d", x, y, expected)
+		}
+		// The same product, reached in different ways, compares equal
+		xy := newMontyNat(m).mul(xMonty, yMonty, m)
+		yx := newMontyNat(m).mul(yMonty, xMonty, m)
+		if xy.equal(yx) != 1 || xy.equal(x.clone().modMul(y, m).toMonty(m)) != 1 {
+			t.Errorf("%+v * %+v: products not equal", x, y)
+		}
+		// Adding m leaves the value the same, but only reduced values compare equal
+		if m.lazyCompatible() {
+			unreduced := (*nat)(xMonty).clone()
+			unreduced.add(1, m.nat)
+			if xMonty.equal((*montyNat)(unreduced)) != 0 {
+				t.Errorf("%+v: equal to the unreduced %+v", xMonty, unreduced)
+			}
+		}
 	}
 }
 
-func TestDiv(t *testing.T) {
-	var hi, lo uint
-	hi, lo = 0xFFFF, 0xFFFF_FFFF_FFFF_AABB
-	d := uint(0xFFFF_FFFF_FFFF_FFFF)
-	expectedQ, expectedR := uint(0x10000), uint(0xAABB)
-	actualQ, actualR := div(hi, lo, d)
-	if actualQ != expectedQ {
-		t.Errorf("%+v != %+v", actualQ, expectedQ)
+func TestMontgomeryMulSingleLimb(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	moduli := []uint{3, 13, _MASK, _MASK >> 1, 1<<(_W-1) | 1}
+	for i := 0; i < 20; i++ {
+		moduli = append(moduli, uint(r.Int63())&_MASK|1)
 	}
-	if actualR != expectedR {
-		t.Errorf("%+v != %+v", actualR, expectedR)
+	for _, mLimb := range moduli {
+		m := modulusFromNat(&nat{[]uint{mLimb}})
+		mBig := new(big.Int).SetUint64(uint64(mLimb))
+		rInv := new(big.Int).ModInverse(new(big.Int).Lsh(big.NewInt(1), _W), mBig)
+		values := []uint{0, 1, mLimb - 1, uint(r.Int63()) % mLimb}
+		for _, x := range values {
+			for _, y := range values {
+				expected := new(big.Int).SetUint64(uint64(x))
+				expected.Mul(expected, new(big.Int).SetUint64(uint64(y)))
+				expected.Mul(expected, rInv)
+				expected.Mod(expected, mBig)
+				actual := new(nat).expandFor(m).montgomeryMul(&nat{[]uint{x}}, &nat{[]uint{y}}, m)
+				if actual.toBig().Cmp(expected) != 0 {
+					t.Errorf("%d * %d / R mod %d: %+v != %v", x, y, mLimb, actual, expected)
+				}
+			}
+		}
 	}
 }
 
-func TestShiftInExamples(t *testing.T) {
-	m := modulusFromNat(&nat{[]uint{13}})
-	x := &nat{[]uint{0}}
-	x.shiftIn(0x7FFF_FFFF_FFFF_FFFF, m)
-	expected := &nat{[]uint{7}}
-	if x.cmpEq(expected) != 1 {
-		t.Errorf("%+v != %+v", x, expected)
+func TestMontgomeryMulLazy(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 200; i++ {
+		size := 1 + r.Intn(4)
+		mNat := (*nat)(nil).Generate(r, size).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[size-1] = mNat.limbs[size-1]>>2 | 1
+		m := modulusFromNat(mNat)
+		if !m.lazyCompatible() {
+			t.Fatalf("%+v should be compatible", m)
+		}
+		twoM := m.nat.clone()
+		twoM.add(1, m.nat)
+		// Inputs anywhere in [0, 2m)
+		randBelow2M := func() *nat {
+			x := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+			x.add(choice(r.Intn(2)), m.nat)
+			return x
+		}
+		x, y := randBelow2M(), randBelow2M()
+		lazy := new(nat).expandFor(m).montgomeryMulLazy(x, y, m)
+		if lazy.cmpLt(twoM) != 1 {
+			t.Errorf("%+v * %+v: %+v isn't smaller than 2m", x, y, lazy)
+		}
+		// A chain of lazy multiplications stays below 2m
+		chained := new(nat).expandFor(m).montgomeryMulLazy(lazy, y, m)
+		if chained.cmpLt(twoM) != 1 {
+			t.Errorf("%+v * %+v: %+v isn't smaller than 2m", lazy, y, chained)
+		}
+
+		xReduced := x.clone().finalReduce(m)
+		yReduced := y.clone().finalReduce(m)
+		expected := new(nat).expandFor(m).montgomeryMul(xReduced, yReduced, m)
+		if lazy.finalReduce(m).cmpEq(expected) != 1 {
+			t.Errorf("%+v * %+v: %+v != %+v", x, y, lazy, expected)
+		}
 	}
-	x.shiftIn(0x7FFF_FFFF_FFFF_FFFF, m)
-	expected = &nat{[]uint{11}}
-	if x.cmpEq(expected) != 1 {
-		t.Errorf("%+v != %+v", x, expected)
+	m := makeBenchmarkModulus()
+	if m.lazyCompatible() {
+		t.Errorf("a full top limb shouldn't be compatible")
 	}
-	m = modulusFromNat(&nat{[]uint{13, 13}})
-	x = &nat{[]uint{0, 0}}
-	x.shiftIn(0x7FFF_FFFF_FFFF_FFFF, m)
-	expected = &nat{[]uint{0x7FFF_FFFF_FFFF_FFFF, 0}}
-	if x.cmpEq(expected) != 1 {
-		t.Errorf("%+v != %+v", x, expected)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("montgomeryMulLazy accepted an incompatible modulus")
+		}
+	}()
+	x := makeBenchmarkValue()
+	new(nat).expandFor(m).montgomeryMulLazy(x, x, m)
+}
+
+func TestMontgomeryReduce(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	check := func(x, y *nat, m *modulus) {
+		product := new(nat).mul(x, y)
+		actual := new(nat).montgomeryReduce(product, m)
+		expected := new(nat).expandFor(m).montgomeryMul(x, y, m)
+		if actual.cmpEq(expected) != 1 || len(actual.limbs) != len(expected.limbs) {
+			t.Errorf("%+v * %+v: %+v != %+v", x, y, actual, expected)
+		}
 	}
-	x.shiftIn(0, m)
-	expected = &nat{[]uint{0x8, 0x6}}
-	if x.cmpEq(expected) != 1 {
-		t.Errorf("%+v != %+v", x, expected)
+	for _, size := range []int{1, 2, 3, 8, 33, 64} {
+		for i := 0; i < 20; i++ {
+			mNat := (*nat)(nil).Generate(r, size).Interface().(*nat)
+			mNat.limbs[0] |= 1
+			mNat.limbs[size-1] |= 1
+			m := modulusFromNat(mNat)
+			x := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+			y := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+			check(x, y, m)
+		}
 	}
+	// The largest product, with a modulus filling its limbs, stresses every carry
+	m := makeBenchmarkModulus()
+	mMinus1 := m.nat.clone()
+	mMinus1.subWord(1)
+	check(mMinus1, mMinus1, m)
 }
 
-func TestMod(t *testing.T) {
-	m := modulusFromNat(&nat{[]uint{13, 13}})
-	x := &nat{[]uint{1, 1, 1}}
-	out := new(nat)
-	out.mod(x, m)
-	expected := &nat{[]uint{9, 8}}
-	if out.cmpEq(expected) != 1 {
-		t.Errorf("%+v != %+v", out, expected)
+func TestMontgomeryMulSafeAliasing(t *testing.T) {
+	m := makeBenchmarkModulus()
+	x := makeBenchmarkValue()
+	y := makeBenchmarkValue()
+	y.limbs[0] = 13
+
+	expectedXY := new(nat).expandFor(m).montgomeryMul(x, y, m)
+	expectedXX := new(nat).expandFor(m).montgomeryMul(x, x.clone(), m)
+
+	out := x.clone()
+	if out.montgomeryMulSafe(out, y, m).cmpEq(expectedXY) != 1 {
+		t.Errorf("out == x: %v != %v", out, expectedXY)
+	}
+	out = y.clone()
+	if out.montgomeryMulSafe(x, out, m).cmpEq(expectedXY) != 1 {
+		t.Errorf("out == y: %v != %v", out, expectedXY)
+	}
+	out = x.clone()
+	if out.montgomeryMulSafe(out, out, m).cmpEq(expectedXX) != 1 {
+		t.Errorf("out == x == y: %v != %v", out, expectedXX)
+	}
+	// Without aliasing, the inputs are left alone
+	xCopy := x.clone()
+	out = new(nat).expandFor(m)
+	if out.montgomeryMulSafe(x, x, m).cmpEq(expectedXX) != 1 || x.cmpEq(xCopy) != 1 {
+		t.Errorf("x == y: %v != %v", out, expectedXX)
 	}
 }
 
@@ -190,6 +1161,25 @@ func TestModAddExamples(t *testing.T) {
 	}
 }
 
+func TestMaybeSubtract(t *testing.T) {
+	m := modulusFromNat(&nat{[]uint{13, 13}})
+	examples := []struct{ x, expected []uint }{
+		{[]uint{12, 13}, []uint{12, 13}},
+		{[]uint{13, 13}, []uint{0, 0}},
+		{[]uint{14, 13}, []uint{1, 0}},
+		{[]uint{0, 14}, []uint{_MASK - 12, 0}},
+		{[]uint{25, 26}, []uint{12, 13}},
+		{[]uint{0, 0}, []uint{0, 0}},
+	}
+	for _, e := range examples {
+		x := &nat{append([]uint{}, e.x...)}
+		x.maybeSubtract(m)
+		if x.cmpEq(&nat{e.expected}) != 1 {
+			t.Errorf("%+v: %+v != %+v", e.x, x, e.expected)
+		}
+	}
+}
+
 func TestExpExamples(t *testing.T) {
 	m := modulusFromNat(&nat{[]uint{13}})
 	x := &nat{[]uint{3}}
@@ -201,10 +1191,275 @@ func TestExpExamples(t *testing.T) {
 	}
 }
 
+func TestSelectNat(t *testing.T) {
+	table := make([]*nat, 16)
+	for i := range table {
+		table[i] = &nat{[]uint{uint(i) + 1, _MASK - uint(i), 1 << i}}
+	}
+	out := &nat{make([]uint, 3)}
+	for i := range table {
+		selectNat(out, table, uint(i))
+		if out.cmpEq(table[i]) != 1 {
+			t.Errorf("index %d: %+v != %+v", i, out, table[i])
+		}
+	}
+	for _, index := range []uint{16, 17, ^uint(0)} {
+		selectNat(out, table, index)
+		if out.isZero() != 1 {
+			t.Errorf("index %d: %+v != 0", index, out)
+		}
+	}
+}
+
+func TestSelectNatReadsAll(t *testing.T) {
+	// An entry that's too short makes selectNat panic if, and only if, it gets read
+	readsEntry := func(index uint, short int) (read bool) {
+		table := make([]*nat, 15)
+		for i := range table {
+			table[i] = &nat{[]uint{uint(i), 0, 0}}
+		}
+		table[short] = &nat{[]uint{0}}
+		defer func() {
+			read = recover() != nil
+		}()
+		selectNat(&nat{make([]uint, 3)}, table, index)
+		return false
+	}
+	for index := uint(0); index < 16; index++ {
+		for short := 0; short < 15; short++ {
+			if !readsEntry(index, short) {
+				t.Errorf("index %d: entry %d wasn't read", index, short)
+			}
+		}
+	}
+}
+
+func TestExpWindowSizes(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	mBytes := make([]byte, 64)
+	r.Read(mBytes)
+	mBytes[len(mBytes)-1] |= 1
+	xBytes := make([]byte, 64)
+	r.Read(xBytes)
+	xBytes[0] = 0
+	e := make([]byte, 19)
+	r.Read(e)
+
+	mBig := new(big.Int).SetBytes(mBytes)
+	expected := new(big.Int).Exp(new(big.Int).SetBytes(xBytes), new(big.Int).SetBytes(e), mBig)
+	m := modulusFromNat(natFromBytes(mBytes))
+	x := natFromBytes(xBytes).expandFor(m)
+	for w := uint(1); w <= 8; w++ {
+		out := new(nat).expWindow(x, e, w, m)
+		actual := new(big.Int).SetBytes(out.fillBytes(make([]byte, len(mBytes))))
+		if actual.Cmp(expected) != 0 {
+			t.Errorf("w = %d: %v != %v", w, actual, expected)
+		}
+	}
+}
+
+func TestExpLadder(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 50; i++ {
+		size := 1 + r.Intn(4)
+		mNat := (*nat)(nil).Generate(r, size).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[size-1] |= 1
+		m := modulusFromNat(mNat)
+		x := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+		e := make([]byte, r.Intn(size*8+1))
+		r.Read(e)
+
+		expected := new(nat).exp(x, e, m)
+		if actual := new(nat).expLadder(x, e, m); actual.cmpEq(expected) != 1 {
+			t.Errorf("%+v^%x: %+v != %+v", x, e, actual, expected)
+		}
+		// Aliasing the output with the base works too
+		if actual := x.clone(); actual.expLadder(actual, e, m).cmpEq(expected) != 1 {
+			t.Errorf("%+v^%x: %+v != %+v", x, e, actual, expected)
+		}
+		unreduced := (*nat)(nil).Generate(r, size+1).Interface().(*nat)
+		if actual, expected := new(nat).expLadder(unreduced, e, m), new(nat).exp(unreduced, e, m); actual.cmpEq(expected) != 1 {
+			t.Errorf("%+v^%x: %+v != %+v", unreduced, e, actual, expected)
+		}
+	}
+	m := modulusFromNat(&nat{[]uint{13}})
+	if actual := new(nat).expLadder(&nat{[]uint{3}}, []byte{0}, m); actual.limbs[0] != 1 {
+		t.Errorf("3^0 mod 13: %+v != 1", actual)
+	}
+	// Like exp, the base doesn't need to be reduced, or have the size of m
+	if actual := new(nat).expLadder(&nat{[]uint{16, 0}}, []byte{2}, m); actual.limbs[0] != 9 || len(actual.limbs) != 1 {
+		t.Errorf("16^2 mod 13: %+v != 9", actual)
+	}
+}
+
+func TestExpLowMem(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 50; i++ {
+		size := 1 + r.Intn(4)
+		mNat := (*nat)(nil).Generate(r, size).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[size-1] |= 1
+		m := modulusFromNat(mNat)
+		x := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+		e := make([]byte, r.Intn(size*8+1))
+		r.Read(e)
+
+		expected := new(nat).exp(x, e, m)
+		for _, w := range []uint{1, 2} {
+			if actual := new(nat).expLowMem(x, e, w, m); actual.cmpEq(expected) != 1 {
+				t.Errorf("w = %d, %+v^%x: %+v != %+v", w, x, e, actual, expected)
+			}
+		}
+	}
+}
+
+func TestExpUnreducedBase(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 50; i++ {
+		size := 1 + r.Intn(8)
+		mNat := (*nat)(nil).Generate(r, size).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[size-1] |= 1
+		m := modulusFromNat(mNat)
+		e := make([]byte, 1+r.Intn(16))
+		r.Read(e)
+		// Bases with more limbs than m, the same number but larger than m, and fewer limbs
+		bases := []*nat{
+			(*nat)(nil).Generate(r, size+1+r.Intn(4)).Interface().(*nat),
+			m.nat.clone(),
+			(*nat)(nil).Generate(r, 1+r.Intn(size)).Interface().(*nat),
+		}
+		bases[1].add(1, (*nat)(nil).Generate(r, size).Interface().(*nat).shiftRight(1))
+		for _, x := range bases {
+			expected := new(nat).exp(new(nat).mod(x, m), e, m)
+			xCopy := x.clone()
+			if actual := new(nat).exp(x, e, m); actual.cmpEq(expected) != 1 || len(actual.limbs) != size {
+				t.Errorf("%+v^%x mod %+v: %+v != %+v", x, e, m, actual, expected)
+			}
+			if x.cmpEq(xCopy) != 1 || len(x.limbs) != len(xCopy.limbs) {
+				t.Errorf("exp modified its base %+v", xCopy)
+			}
+			for _, w := range []uint{1, 2} {
+				if actual := new(nat).expLowMem(x, e, w, m); actual.cmpEq(expected) != 1 || len(actual.limbs) != size {
+					t.Errorf("w = %d, %+v^%x mod %+v: %+v != %+v", w, x, e, m, actual, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestExpLeadingZeros(t *testing.T) {
+	m := modulusFromNat(&nat{[]uint{13, 13}})
+	x := &nat{[]uint{3, 0}}
+	expected := new(big.Int).Exp(big.NewInt(3), big.NewInt(5), m.nat.toBig())
+	short, padded := []byte{5}, []byte{0, 0, 5}
+	for _, e := range [][]byte{short, padded} {
+		if actual := new(nat).exp(x, e, m); actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("%+v^%x: %+v != %v", x, e, actual, expected)
+		}
+	}
+	// Both exponents go through the same number of rounds, based on the size of m
+	if a, b := len(padExponent(short, m)), len(padExponent(padded, m)); a != b || a != m.byteLen() {
+		t.Errorf("padded exponent lengths %d and %d, expected %d", a, b, m.byteLen())
+	}
+	long := make([]byte, m.byteLen()+3)
+	if len(padExponent(long, m)) != len(long) {
+		t.Errorf("long exponent got padded")
+	}
+}
+
+func TestExpNat(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 50; i++ {
+		size := 1 + r.Intn(4)
+		mNat := (*nat)(nil).Generate(r, size).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[size-1] |= 1
+		m := modulusFromNat(mNat)
+		x := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+		// Exponents both smaller, and larger than the modulus
+		e := (*nat)(nil).Generate(r, 1+r.Intn(2*size)).Interface().(*nat)
+
+		expected := new(nat).exp(x, e.toBig().Bytes(), m)
+		if actual := new(nat).expNat(x, e, m); actual.cmpEq(expected) != 1 {
+			t.Errorf("%+v^%+v mod %+v: %+v != %+v", x, e, m, actual, expected)
+		}
+		// Leading zero limbs don't change the result
+		if actual := new(nat).expNat(x, e.clone().expand(len(e.limbs)+2), m); actual.cmpEq(expected) != 1 {
+			t.Errorf("%+v^%+v mod %+v, with zero limbs: %+v != %+v", x, e, m, actual, expected)
+		}
+	}
+}
+
+func TestLimbWindowAt(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 50; i++ {
+		e := (*nat)(nil).Generate(r, 1+r.Intn(4)).Interface().(*nat)
+		eBytes := e.toBig().Bytes()
+		for _, w := range []uint{1, 2, 3, 4} {
+			// Going past the end of e reads zeros, in both representations
+			for bit := uint(0); bit < uint(len(e.limbs)+1)*_W; bit += w {
+				if actual, expected := limbWindowAt(e.limbs, bit, w), windowAt(eBytes, bit, w); actual != expected {
+					t.Errorf("%+v, w = %d, bit %d: %d != %d", e, w, bit, actual, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestExpShort(t *testing.T) {
+	m := makeBenchmarkModulus()
+	x := makeBenchmarkValue()
+	for _, e := range []uint{0, 1, 2, 3, 17, 65537, 0x7FFF_FFFF} {
+		eBytes := []byte{byte(e >> 24), byte(e >> 16), byte(e >> 8), byte(e)}
+		expected := new(nat).exp(x, eBytes, m)
+		actual := new(nat).expShort(x, e, m)
+		if actual.cmpEq(expected) != 1 {
+			t.Errorf("e = %d: %+v != %+v", e, actual, expected)
+		}
+	}
+}
+
+func TestExpVartime(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		mNat := (*nat)(nil).Generate(r, 1+r.Intn(8)).Interface().(*nat)
+		mNat.limbs[0] |= 1
+		mNat.limbs[len(mNat.limbs)-1] |= 1
+		// Cover moduli with and without room for lazy multiplications
+		if i%2 == 0 {
+			mNat.limbs[len(mNat.limbs)-1] |= 1 << (_W - 1)
+		} else {
+			mNat.limbs[len(mNat.limbs)-1] >>= 2
+			mNat.limbs[len(mNat.limbs)-1] |= 1
+		}
+		m := modulusFromNat(mNat)
+		if m.lazyCompatible() != (i%2 == 1) {
+			t.Fatalf("%+v: wrong lazy compatibility", m)
+		}
+		x := new(nat).mod((*nat)(nil).Generate(r, len(m.nat.limbs)).Interface().(*nat), m)
+		// Cover every window size, with both dense and sparse exponents
+		e := make([]byte, r.Intn(80))
+		r.Read(e)
+		if i%2 == 0 {
+			for j := range e {
+				e[j] &= byte(r.Intn(256)) & byte(r.Intn(256))
+			}
+		}
+
+		expected := new(big.Int).Exp(x.toBig(), new(big.Int).SetBytes(e), m.nat.toBig())
+		actual := new(nat).expVartime(x, e, m)
+		if actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("%+v^%x mod %+v: %+v != %v", x, e, m.nat, actual, expected)
+		}
+	}
+}
+
 func makeBenchmarkModulus() *modulus {
 	m := make([]uint, 32)
 	for i := 0; i < 32; i++ {
-		m[i] = 0x7FFF_FFFF_FFFF_FFFF
+		m[i] = _MASK
 	}
 	return modulusFromNat(&nat{limbs: m})
 }
@@ -212,7 +1467,7 @@ func makeBenchmarkModulus() *modulus {
 func makeBenchmarkValue() *nat {
 	x := make([]uint, 32)
 	for i := 0; i < 32; i++ {
-		x[i] = 0x7FFF_FFFF_FFFF_FFFA
+		x[i] = _MASK - 5
 	}
 	return &nat{limbs: x}
 }
@@ -238,6 +1493,26 @@ func BenchmarkModAdd(b *testing.B) {
 	}
 }
 
+func BenchmarkSetBytes(b *testing.B) {
+	m := makeBenchmarkModulus()
+	in := m.nat.bytes(m)
+	in[0] = 0
+
+	b.Run("Allocating", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			new(nat).mod(natFromBytes(in), m)
+		}
+	})
+	b.Run("Reusing", func(b *testing.B) {
+		b.ReportAllocs()
+		x := new(nat)
+		for i := 0; i < b.N; i++ {
+			x.setBytes(in, m)
+		}
+	})
+}
+
 func BenchmarkModSub(b *testing.B) {
 	b.StopTimer()
 
@@ -277,6 +1552,111 @@ func BenchmarkMontgomeryMul(b *testing.B) {
 	}
 }
 
+func BenchmarkMontgomeryMulChain(b *testing.B) {
+	mLimbs := make([]uint, 32)
+	for i := range mLimbs {
+		mLimbs[i] = _MASK
+	}
+	mLimbs[31] >>= 2
+	m := modulusFromNat(&nat{mLimbs})
+	x := makeBenchmarkValue()
+	x.limbs[31] >>= 3
+	const chain = 16
+
+	b.Run("reduced", func(b *testing.B) {
+		acc, scratch := x.clone(), new(nat).expandFor(m)
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < chain; j++ {
+				scratch.montgomeryMul(acc, x, m)
+				acc, scratch = scratch, acc
+			}
+		}
+	})
+	b.Run("lazy", func(b *testing.B) {
+		acc, scratch := x.clone(), new(nat).expandFor(m)
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < chain; j++ {
+				scratch.montgomeryMulLazy(acc, x, m)
+				acc, scratch = scratch, acc
+			}
+			acc.finalReduce(m)
+		}
+	})
+}
+
+func TestMontgomeryLoopFixed(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, size := range []int{33, 49, 66} {
+		fixed := montgomeryLoopFixed(size)
+		if fixed == nil {
+			t.Fatalf("no specialization for %d limbs", size)
+		}
+		for i := 0; i < 20; i++ {
+			mNat := (*nat)(nil).Generate(r, size).Interface().(*nat)
+			mNat.limbs[0] |= 1
+			mNat.limbs[size-1] |= 1
+			m := modulusFromNat(mNat)
+			x := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+			y := new(nat).mod((*nat)(nil).Generate(r, size).Interface().(*nat), m)
+
+			expected := new(nat).expandFor(m)
+			expectedOverflow := montgomeryLoopAnySize(expected.limbs, x.limbs, y.limbs, m.nat.limbs, m.m0inv)
+			actual := new(nat).expandFor(m)
+			actualOverflow := fixed(actual.limbs, x.limbs, y.limbs, m.nat.limbs, m.m0inv)
+			if actualOverflow != expectedOverflow || actual.cmpEq(expected) != 1 {
+				t.Errorf("%d limbs: %+v, %d != %+v, %d", size, actual, actualOverflow, expected, expectedOverflow)
+			}
+		}
+	}
+	if montgomeryLoopFixed(32) != nil {
+		t.Errorf("unexpected specialization for 32 limbs")
+	}
+}
+
+func BenchmarkMontgomeryMulSizes(b *testing.B) {
+	for _, size := range []int{16, 32, 64} {
+		mLimbs := make([]uint, size)
+		xLimbs := make([]uint, size)
+		for i := 0; i < size; i++ {
+			mLimbs[i] = _MASK
+			xLimbs[i] = _MASK - 5
+		}
+		m := modulusFromNat(&nat{mLimbs})
+		x := &nat{xLimbs}
+		out := new(nat).expandFor(m)
+
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				out.montgomeryMul(x, x, m)
+			}
+		})
+	}
+}
+
+func BenchmarkMontgomeryLoopFixed(b *testing.B) {
+	for _, size := range []int{33, 49, 66} {
+		mLimbs := make([]uint, size)
+		xLimbs := make([]uint, size)
+		for i := 0; i < size; i++ {
+			mLimbs[i] = _MASK
+			xLimbs[i] = _MASK - 5
+		}
+		out := make([]uint, size)
+		fixed := montgomeryLoopFixed(size)
+
+		b.Run(fmt.Sprintf("%d/any", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				montgomeryLoopAnySize(out, xLimbs, xLimbs, mLimbs, 1)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/fixed", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				fixed(out, xLimbs, xLimbs, mLimbs, 1)
+			}
+		})
+	}
+}
+
 func BenchmarkModMul(b *testing.B) {
 	b.StopTimer()
 
@@ -290,6 +1670,113 @@ func BenchmarkModMul(b *testing.B) {
 	}
 }
 
+func BenchmarkExpWindow(b *testing.B) {
+	x := makeBenchmarkValue()
+	e := makeBenchmarkExponent()
+	out := makeBenchmarkValue()
+	m := makeBenchmarkModulus()
+
+	for w := uint(1); w <= 6; w++ {
+		b.Run(fmt.Sprintf("w=%d", w), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				out.expWindow(x, e, w, m)
+			}
+		})
+	}
+}
+
+func BenchmarkExpLowMem(b *testing.B) {
+	// A 4096 bit modulus, where the table size matters the most
+	mLimbs := make([]uint, 66)
+	for i := range mLimbs {
+		mLimbs[i] = _MASK
+	}
+	m := modulusFromNat(&nat{mLimbs})
+	x := new(nat).expandFor(m)
+	for i := range x.limbs {
+		x.limbs[i] = _MASK - 5
+	}
+	e := make([]byte, m.byteLen())
+	for i := range e {
+		e[i] = 0xA5
+	}
+	out := new(nat)
+
+	for _, w := range []uint{1, 2, 4} {
+		b.Run(fmt.Sprintf("w=%d", w), func(b *testing.B) {
+			tableBytes := ((1 << w) - 1) * len(m.nat.limbs) * bits.UintSize / 8
+			b.ReportMetric(float64(tableBytes), "table-B")
+			for i := 0; i < b.N; i++ {
+				out.expLowMem(x, e, w, m)
+			}
+		})
+	}
+}
+
+func BenchmarkSelectNat(b *testing.B) {
+	table := make([]*nat, 15)
+	for i := range table {
+		table[i] = makeBenchmarkValue()
+	}
+	out := makeBenchmarkValue()
+
+	b.Run("masked", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			selectNat(out, table, uint(i)%15)
+		}
+	})
+	// For comparison only: this leaks the index through memory accesses
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(out.limbs, table[i%15].limbs)
+		}
+	})
+}
+
+func BenchmarkExp65537(b *testing.B) {
+	x := makeBenchmarkValue()
+	out := makeBenchmarkValue()
+	m := makeBenchmarkModulus()
+
+	for i := 0; i < b.N; i++ {
+		out.exp(x, []byte{1, 0, 1}, m)
+	}
+}
+
+func BenchmarkExpShort65537(b *testing.B) {
+	x := makeBenchmarkValue()
+	out := makeBenchmarkValue()
+	m := makeBenchmarkModulus()
+
+	for i := 0; i < b.N; i++ {
+		out.expShort(x, 65537, m)
+	}
+}
+
+func BenchmarkExpVartime(b *testing.B) {
+	x := makeBenchmarkValue()
+	out := makeBenchmarkValue()
+	m := makeBenchmarkModulus()
+	dense := make([]byte, 256)
+	for i := range dense {
+		dense[i] = 0xA5
+	}
+	exponents := map[string][]byte{"sparse": makeBenchmarkExponent(), "dense": dense}
+
+	for name, e := range exponents {
+		b.Run(name+"/window", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				out.exp(x, e, m)
+			}
+		})
+		b.Run(name+"/sliding", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				out.expVartime(x, e, m)
+			}
+		})
+	}
+}
+
 func BenchmarkExpBig(b *testing.B) {
 	b.StopTimer()
 
@@ -315,6 +1802,7 @@ func BenchmarkExp(b *testing.B) {
 	out := makeBenchmarkValue()
 	m := makeBenchmarkModulus()
 
+	b.ReportAllocs()
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
 		out.exp(x, e, m)
@@ -0,0 +1,68 @@
+package ctrsa
+
+import (
+	"encoding/pem"
+	"errors"
+)
+
+const (
+	pemTypePublicKey  = "RSA PUBLIC KEY"
+	pemTypePrivateKey = "RSA PRIVATE KEY"
+)
+
+var (
+	errPEMNoPublicKey  = errors.New("crypto/rsa: no \"" + pemTypePublicKey + "\" PEM block found")
+	errPEMNoPrivateKey = errors.New("crypto/rsa: no \"" + pemTypePrivateKey + "\" PEM block found")
+)
+
+// findPEMBlock returns the bytes of the first PEM block with a given type, skipping over other blocks
+func findPEMBlock(pemBytes []byte, blockType string) []byte {
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			return nil
+		}
+		if block.Type == blockType {
+			return block.Bytes
+		}
+	}
+}
+
+// LoadPublicKeyPEM loads a public key from the first "RSA PUBLIC KEY" PEM block
+//
+// Other blocks, such as certificates, or private keys, are skipped. The contents
+// of the block are parsed with ParsePKCS1PublicKey.
+func LoadPublicKeyPEM(pemBytes []byte) (*PublicKey, error) {
+	der := findPEMBlock(pemBytes, pemTypePublicKey)
+	if der == nil {
+		return nil, errPEMNoPublicKey
+	}
+	return ParsePKCS1PublicKey(der)
+}
+
+// LoadPrivateKeyPEM loads a private key from the first "RSA PRIVATE KEY" PEM block
+//
+// Other blocks are skipped. The contents of the block are parsed with ParsePKCS1PrivateKey.
+// Encrypted PEM blocks are not supported.
+func LoadPrivateKeyPEM(pemBytes []byte) (*PrivateKey, error) {
+	der := findPEMBlock(pemBytes, pemTypePrivateKey)
+	if der == nil {
+		return nil, errPEMNoPrivateKey
+	}
+	return ParsePKCS1PrivateKey(der)
+}
+
+// MarshalPublicKeyPEM encodes a public key as an "RSA PUBLIC KEY" PEM block
+//
+// The contents of the block come from MarshalPKCS1PublicKey.
+func MarshalPublicKeyPEM(pub *PublicKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePublicKey, Bytes: MarshalPKCS1PublicKey(pub)})
+}
+
+// MarshalPrivateKeyPEM encodes a private key as an "RSA PRIVATE KEY" PEM block
+//
+// The contents of the block come from MarshalPKCS1PrivateKey.
+func MarshalPrivateKeyPEM(priv *PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePrivateKey, Bytes: MarshalPKCS1PrivateKey(priv)})
+}
@@ -0,0 +1,78 @@
+package ctrsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestLoadPEM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pemBytes bytes.Buffer
+	pem.Encode(&pemBytes, &pem.Block{Type: "CERTIFICATE", Bytes: []byte("not a certificate")})
+	pem.Encode(&pemBytes, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pemBytes.WriteString("some text in between blocks\n")
+	pem.Encode(&pemBytes, &pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+
+	pub, err := LoadPublicKeyPEM(pemBytes.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.N.Cmp(key.N) != 0 || pub.E != key.E {
+		t.Errorf("loaded public key doesn't match")
+	}
+	priv, err := LoadPrivateKeyPEM(pemBytes.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.N.Cmp(key.N) != 0 || priv.D.Cmp(key.D) != 0 {
+		t.Errorf("loaded private key doesn't match")
+	}
+}
+
+func TestLoadPEMMissingBlock(t *testing.T) {
+	var pemBytes bytes.Buffer
+	pem.Encode(&pemBytes, &pem.Block{Type: "CERTIFICATE", Bytes: []byte("not a certificate")})
+	pem.Encode(&pemBytes, &pem.Block{Type: "PUBLIC KEY", Bytes: []byte("not a PKIX key")})
+
+	for _, input := range [][]byte{nil, []byte("no PEM here"), pemBytes.Bytes()} {
+		if _, err := LoadPublicKeyPEM(input); err != errPEMNoPublicKey {
+			t.Errorf("%q: expected %s, got %v", input, errPEMNoPublicKey, err)
+		}
+		if _, err := LoadPrivateKeyPEM(input); err != errPEMNoPrivateKey {
+			t.Errorf("%q: expected %s, got %v", input, errPEMNoPrivateKey, err)
+		}
+	}
+}
+
+func TestMarshalPEMRoundtrip(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := LoadPublicKeyPEM(MarshalPublicKeyPEM(&priv.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Errorf("public key doesn't round trip")
+	}
+	priv2, err := LoadPrivateKeyPEM(MarshalPrivateKeyPEM(priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv2.Equal(priv) {
+		t.Errorf("private key doesn't round trip")
+	}
+	// The PEM encoding should also be readable by the standard library
+	block, _ := pem.Decode(MarshalPrivateKeyPEM(priv))
+	if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		t.Error(err)
+	}
+}
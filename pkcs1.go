@@ -0,0 +1,171 @@
+package ctrsa
+
+import (
+	"encoding/asn1"
+	"errors"
+	"math"
+	"math/big"
+)
+
+// pkcs1PublicKey reflects the ASN.1 structure of a PKCS #1 public key
+type pkcs1PublicKey struct {
+	N *big.Int
+	E *big.Int
+}
+
+// pkcs1PrivateKey reflects the ASN.1 structure of a PKCS #1 private key
+type pkcs1PrivateKey struct {
+	Version int
+	N       *big.Int
+	E       int
+	D       *big.Int
+	P       *big.Int
+	Q       *big.Int
+	// Some encoders leave out the CRT values, in which case we calculate them.
+	Dp   *big.Int `asn1:"optional"`
+	Dq   *big.Int `asn1:"optional"`
+	Qinv *big.Int `asn1:"optional"`
+
+	AdditionalPrimes []pkcs1AdditionalRSAPrime `asn1:"optional,omitempty"`
+}
+
+// pkcs1AdditionalRSAPrime holds the CRT values for the third and subsequent primes
+type pkcs1AdditionalRSAPrime struct {
+	Prime *big.Int
+	Exp   *big.Int
+	Coeff *big.Int
+}
+
+var (
+	errPKCS1Version      = errors.New("crypto/rsa: unsupported PKCS #1 private key version")
+	errPKCS1CRTValues    = keyError("crypto/rsa: inconsistent CRT values in PKCS #1 private key")
+	errPKCS1TrailingData = errors.New("crypto/rsa: trailing data after PKCS #1 key")
+	errPKCS1Negative     = keyError("crypto/rsa: negative integer in PKCS #1 key")
+)
+
+// ParsePKCS1PublicKey parses a public key in PKCS #1, ASN.1 DER form
+//
+// This has the form "RSA PUBLIC KEY", as produced by x509.MarshalPKCS1PublicKey.
+// The key goes through the same checks as NewPublicKey.
+func ParsePKCS1PublicKey(der []byte) (*PublicKey, error) {
+	var pub pkcs1PublicKey
+	rest, err := asn1.Unmarshal(der, &pub)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errPKCS1TrailingData
+	}
+	if pub.N.Sign() < 0 || pub.E.Sign() < 0 {
+		return nil, errPKCS1Negative
+	}
+	if !pub.E.IsUint64() || pub.E.Uint64() > math.MaxUint32 {
+		return nil, errPublicExponentLarge
+	}
+	return NewPublicKey(pub.N.Bytes(), uint(pub.E.Uint64()))
+}
+
+// ParsePKCS1PrivateKey parses a private key in PKCS #1, ASN.1 DER form
+//
+// This has the form "RSA PRIVATE KEY", as produced by x509.MarshalPKCS1PrivateKey.
+// The CRT values stored in the key are used directly, instead of being recalculated,
+// after checking that they're consistent with the primes and the private exponent.
+// The key also goes through the checks of PrivateKey.Validate.
+func ParsePKCS1PrivateKey(der []byte) (*PrivateKey, error) {
+	var priv pkcs1PrivateKey
+	rest, err := asn1.Unmarshal(der, &priv)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errPKCS1TrailingData
+	}
+	if priv.Version > 1 {
+		return nil, errPKCS1Version
+	}
+	values := []*big.Int{priv.N, priv.D, priv.P, priv.Q, priv.Dp, priv.Dq, priv.Qinv}
+	for _, a := range priv.AdditionalPrimes {
+		values = append(values, a.Prime, a.Exp, a.Coeff)
+	}
+	for _, v := range values {
+		if v != nil && v.Sign() < 0 {
+			return nil, errPKCS1Negative
+		}
+	}
+
+	key := &PrivateKey{
+		PublicKey: PublicKey{N: priv.N, E: priv.E},
+		D:         priv.D,
+		Primes:    []*big.Int{priv.P, priv.Q},
+	}
+	for _, a := range priv.AdditionalPrimes {
+		key.Primes = append(key.Primes, a.Prime)
+	}
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	if priv.Dp == nil || priv.Dq == nil || priv.Qinv == nil {
+		key.Precompute()
+		return key, nil
+	}
+	key.Precomputed = PrecomputedValues{Dp: priv.Dp, Dq: priv.Dq, Qinv: priv.Qinv}
+	r := new(big.Int).Mul(priv.P, priv.Q)
+	for _, a := range priv.AdditionalPrimes {
+		key.Precomputed.CRTValues = append(key.Precomputed.CRTValues, CRTValue{
+			Exp:   a.Exp,
+			Coeff: a.Coeff,
+			R:     new(big.Int).Set(r),
+		})
+		r.Mul(r, a.Prime)
+	}
+	if !precomputedConsistent(key) {
+		return nil, errPKCS1CRTValues
+	}
+	return key, nil
+}
+
+// MarshalPKCS1PublicKey converts a public key to PKCS #1, ASN.1 DER form
+//
+// This produces the same encoding as x509.MarshalPKCS1PublicKey.
+func MarshalPKCS1PublicKey(pub *PublicKey) []byte {
+	der, _ := asn1.Marshal(pkcs1PublicKey{
+		N: pub.N,
+		E: big.NewInt(int64(pub.E)),
+	})
+	return der
+}
+
+// MarshalPKCS1PrivateKey converts a private key to PKCS #1, ASN.1 DER form
+//
+// Like x509.MarshalPKCS1PrivateKey, which produces the same encoding, this calls
+// Precompute on the key, in order to include its CRT values.
+func MarshalPKCS1PrivateKey(priv *PrivateKey) []byte {
+	priv.Precompute()
+
+	// Keys with more than two primes use the second version of the format
+	version := 0
+	if len(priv.Primes) > 2 {
+		version = 1
+	}
+	key := pkcs1PrivateKey{
+		Version: version,
+		N:       priv.N,
+		E:       priv.E,
+		D:       priv.D,
+		P:       priv.Primes[0],
+		Q:       priv.Primes[1],
+		Dp:      priv.Precomputed.Dp,
+		Dq:      priv.Precomputed.Dq,
+		Qinv:    priv.Precomputed.Qinv,
+	}
+	for i, values := range priv.Precomputed.CRTValues {
+		key.AdditionalPrimes = append(key.AdditionalPrimes, pkcs1AdditionalRSAPrime{
+			Prime: priv.Primes[2+i],
+			Exp:   values.Exp,
+			Coeff: values.Coeff,
+		})
+	}
+	der, _ := asn1.Marshal(key)
+	return der
+}
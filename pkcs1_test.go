@@ -0,0 +1,162 @@
+package ctrsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func TestParsePKCS1PublicKey(t *testing.T) {
+	for _, size := range []int{512, 1024, 2048} {
+		key, err := rsa.GenerateKey(rand.Reader, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := ParsePKCS1PublicKey(x509.MarshalPKCS1PublicKey(&key.PublicKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub.N.Cmp(key.N) != 0 || pub.E != key.E {
+			t.Errorf("%d: parsed key doesn't match %+v", size, key.PublicKey)
+		}
+	}
+}
+
+func TestParsePKCS1PublicKeyInvalid(t *testing.T) {
+	marshal := func(n, e *big.Int) []byte {
+		der, err := asn1.Marshal(pkcs1PublicKey{N: n, E: e})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	n := rsaPrivateKey.N
+	examples := map[string][]byte{
+		"negative modulus":  marshal(new(big.Int).Neg(n), big.NewInt(65537)),
+		"negative exponent": marshal(n, big.NewInt(-65537)),
+		"huge exponent":     marshal(n, new(big.Int).Lsh(big.NewInt(1), 64)),
+		"large exponent":    marshal(n, big.NewInt(1<<32+1)),
+		"small exponent":    marshal(n, big.NewInt(1)),
+		"even modulus":      marshal(new(big.Int).Add(n, big.NewInt(1)), big.NewInt(65537)),
+		"trailing data":     append(marshal(n, big.NewInt(65537)), 0),
+		"garbage":           {0x30, 0x03, 0x02},
+	}
+	for name, der := range examples {
+		if _, err := ParsePKCS1PublicKey(der); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParsePKCS1PrivateKey(t *testing.T) {
+	for _, nprimes := range []int{2, 3, 4} {
+		key, err := rsa.GenerateMultiPrimeKey(rand.Reader, nprimes, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		priv, err := ParsePKCS1PrivateKey(x509.MarshalPKCS1PrivateKey(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.N.Cmp(key.N) != 0 || priv.E != key.E || priv.D.Cmp(key.D) != 0 {
+			t.Errorf("%d primes: parsed key doesn't match", nprimes)
+		}
+		if priv.Precomputed.Qinv.Cmp(key.Precomputed.Qinv) != 0 || len(priv.Precomputed.CRTValues) != nprimes-2 {
+			t.Errorf("%d primes: parsed CRT values don't match", nprimes)
+		}
+		for i, values := range priv.Precomputed.CRTValues {
+			if values.R.Cmp(key.Precomputed.CRTValues[i].R) != 0 {
+				t.Errorf("%d primes: R[%d] %v != %v", nprimes, i, values.R, key.Precomputed.CRTValues[i].R)
+			}
+		}
+		testKeyBasics(t, priv)
+	}
+}
+
+func TestParsePKCS1PrivateKeyInvalid(t *testing.T) {
+	key, err := rsa.GenerateMultiPrimeKey(rand.Reader, 3, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marshal := func(modify func(*pkcs1PrivateKey)) []byte {
+		priv := pkcs1PrivateKey{
+			N:    key.N,
+			E:    key.E,
+			D:    key.D,
+			P:    key.Primes[0],
+			Q:    key.Primes[1],
+			Dp:   key.Precomputed.Dp,
+			Dq:   key.Precomputed.Dq,
+			Qinv: key.Precomputed.Qinv,
+			AdditionalPrimes: []pkcs1AdditionalRSAPrime{{
+				Prime: key.Primes[2],
+				Exp:   key.Precomputed.CRTValues[0].Exp,
+				Coeff: key.Precomputed.CRTValues[0].Coeff,
+			}},
+		}
+		modify(&priv)
+		der, err := asn1.Marshal(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	plusOne := func(x *big.Int) *big.Int {
+		return new(big.Int).Add(x, bigOne)
+	}
+	if _, err := ParsePKCS1PrivateKey(marshal(func(*pkcs1PrivateKey) {})); err != nil {
+		t.Fatalf("unmodified key: %s", err)
+	}
+	examples := map[string][]byte{
+		"version":          marshal(func(k *pkcs1PrivateKey) { k.Version = 2 }),
+		"negative d":       marshal(func(k *pkcs1PrivateKey) { k.D = new(big.Int).Neg(k.D) }),
+		"wrong modulus":    marshal(func(k *pkcs1PrivateKey) { k.N = new(big.Int).Add(k.N, big.NewInt(2)) }),
+		"wrong exponent":   marshal(func(k *pkcs1PrivateKey) { k.D = plusOne(k.D) }),
+		"wrong dp":         marshal(func(k *pkcs1PrivateKey) { k.Dp = plusOne(k.Dp) }),
+		"wrong dq":         marshal(func(k *pkcs1PrivateKey) { k.Dq = plusOne(k.Dq) }),
+		"wrong qinv":       marshal(func(k *pkcs1PrivateKey) { k.Qinv = plusOne(k.Qinv) }),
+		"unreduced qinv":   marshal(func(k *pkcs1PrivateKey) { k.Qinv = new(big.Int).Add(k.Qinv, k.P) }),
+		"wrong prime exp":  marshal(func(k *pkcs1PrivateKey) { k.AdditionalPrimes[0].Exp = plusOne(k.AdditionalPrimes[0].Exp) }),
+		"wrong prime coef": marshal(func(k *pkcs1PrivateKey) { k.AdditionalPrimes[0].Coeff = plusOne(k.AdditionalPrimes[0].Coeff) }),
+		"trailing data":    append(marshal(func(*pkcs1PrivateKey) {}), 0),
+	}
+	for name, der := range examples {
+		if _, err := ParsePKCS1PrivateKey(der); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMarshalPKCS1MatchesX509(t *testing.T) {
+	for _, nprimes := range []int{2, 3} {
+		priv, err := GenerateMultiPrimeKey(rand.Reader, nprimes, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: priv.N, E: priv.E},
+			D:         priv.D,
+			Primes:    priv.Primes,
+		}
+		key.Precompute()
+
+		if actual, expected := MarshalPKCS1PublicKey(&priv.PublicKey), x509.MarshalPKCS1PublicKey(&key.PublicKey); !bytes.Equal(actual, expected) {
+			t.Errorf("%d primes: public key %x != %x", nprimes, actual, expected)
+		}
+		der := MarshalPKCS1PrivateKey(priv)
+		if expected := x509.MarshalPKCS1PrivateKey(key); !bytes.Equal(der, expected) {
+			t.Errorf("%d primes: private key %x != %x", nprimes, der, expected)
+		}
+		parsed, err := ParsePKCS1PrivateKey(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.Equal(priv) {
+			t.Errorf("%d primes: private key doesn't round trip", nprimes)
+		}
+	}
+}
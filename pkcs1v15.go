@@ -6,7 +6,6 @@ package ctrsa
 
 import (
 	"crypto"
-	"crypto/subtle"
 	"errors"
 	"io"
 
@@ -41,21 +40,10 @@ func EncryptPKCS1v15(rand io.Reader, pub *PublicKey, msg []byte) ([]byte, error)
 	if err := checkPub(pub); err != nil {
 		return nil, err
 	}
-	k := pub.Size()
-	if len(msg) > k-11 {
-		return nil, ErrMessageTooLong
-	}
-
-	// EM = 0x00 || 0x02 || PS || 0x00 || M
-	em := make([]byte, k)
-	em[1] = 2
-	ps, mm := em[2:len(em)-len(msg)-1], em[len(em)-len(msg):]
-	err := nonZeroRandomBytes(ps, rand)
+	em, err := pad2(rand, msg, pub.Size())
 	if err != nil {
 		return nil, err
 	}
-	em[len(em)-len(msg)-1] = 0
-	copy(mm, msg)
 
 	m := natFromBytes(em)
 	c := encrypt(new(nat), pub, m)
@@ -79,7 +67,7 @@ func DecryptPKCS1v15(rand io.Reader, priv *PrivateKey, ciphertext []byte) ([]byt
 	if err != nil {
 		return nil, err
 	}
-	if valid == 0 {
+	if valid != 1 {
 		return nil, ErrDecryption
 	}
 	return out[index:], nil
@@ -124,53 +112,109 @@ func DecryptPKCS1v15SessionKey(rand io.Reader, priv *PrivateKey, ciphertext []by
 		return ErrDecryption
 	}
 
-	valid &= subtle.ConstantTimeEq(int32(len(em)-index), int32(len(key)))
-	subtle.ConstantTimeCopy(valid, key, em[len(em)-len(key):])
+	valid &= ctEq(uint(len(em)-index), uint(len(key)))
+	// key is the fallback, and only gets overwritten if the padding was valid
+	ctCopyBytes(valid, key, em[len(em)-len(key):])
 	return nil
 }
 
 // decryptPKCS1v15 decrypts ciphertext using priv and blinds the operation if
-// rand is not nil. It returns one or zero in valid that indicates whether the
+// rand is not nil. It returns 1 or 0 in valid that indicates whether the
 // plaintext was correctly structured. In either case, the plaintext is
 // returned in em so that it may be read independently of whether it was valid
 // in order to maintain constant memory access patterns. If the plaintext was
 // valid then index contains the index of the original message in em.
-func decryptPKCS1v15(rand io.Reader, priv *PrivateKey, ciphertext []byte) (valid int, em []byte, index int, err error) {
+//
+// None of the checks on the padding branch on their results: they get
+// accumulated into valid, so that there's no padding oracle.
+func decryptPKCS1v15(rand io.Reader, priv *PrivateKey, ciphertext []byte) (valid choice, em []byte, index int, err error) {
 	k := priv.Size()
 	if k < 11 {
 		err = ErrDecryption
 		return
 	}
 
-	c := natFromBytes(ciphertext)
+	c, err := natFromBytesChecked(ciphertext, modulusFromNat(natFromBig(priv.N)))
+	if err != nil {
+		err = ErrDecryption
+		return
+	}
 	m, err := decrypt(rand, priv, c)
 	if err != nil {
 		return
 	}
 
 	em = m.fillBytes(make([]byte, k))
-	firstByteIsZero := subtle.ConstantTimeByteEq(em[0], 0)
-	secondByteIsTwo := subtle.ConstantTimeByteEq(em[1], 2)
+	m.clear()
+	valid, index = pkcs1v15Unpad(em)
+	return valid, em, index, nil
+}
+
+// pkcs1v15Unpad checks that em = 0x00 || 0x02 || PS || 0x00 || M, with PS at least 8 non-zero bytes
+//
+// This returns 1 in valid if the padding is correct, along with the index of M,
+// and 0 and 0 otherwise. Only the length of em is leaked.
+func pkcs1v15Unpad(em []byte) (valid choice, index int) {
+	valid = ctEq(uint(em[0]), 0) & ctEq(uint(em[1]), 2)
 
 	// The remainder of the plaintext must be a string of non-zero random
 	// octets, followed by a 0, followed by the message.
 	//   lookingForIndex: 1 iff we are still looking for the zero.
-	//   index: the offset of the first zero byte.
-	lookingForIndex := 1
-
+	//   zeroIndex: the offset of the first zero byte.
+	lookingForIndex := choice(1)
+	zeroIndex := uint(0)
 	for i := 2; i < len(em); i++ {
-		equals0 := subtle.ConstantTimeByteEq(em[i], 0)
-		index = subtle.ConstantTimeSelect(lookingForIndex&equals0, i, index)
-		lookingForIndex = subtle.ConstantTimeSelect(equals0, 0, lookingForIndex)
+		equals0 := ctEq(uint(em[i]), 0)
+		zeroIndex = ctIfElse(lookingForIndex&equals0, uint(i), zeroIndex)
+		lookingForIndex &= 1 ^ equals0
 	}
 
 	// The PS padding must be at least 8 bytes long, and it starts two
 	// bytes into em.
-	validPS := subtle.ConstantTimeLessOrEq(2+8, index)
+	valid &= (1 ^ lookingForIndex) & ctGeq(zeroIndex, 2+8)
+	index = int(ctIfElse(valid, zeroIndex+1, 0))
+	return valid, index
+}
 
-	valid = firstByteIsZero & secondByteIsTwo & (^lookingForIndex & 1) & validPS
-	index = subtle.ConstantTimeSelect(valid, index+1, 0)
-	return valid, em, index, nil
+// pkcs1v15Block lays out EM = 0x00 || blockType || PS || 0x00 || M, for a modulus of k bytes
+//
+// PS is left for the caller to fill in, and is returned as a subslice of EM. It
+// takes up the rest of the block, which needs to leave room for at least 8 bytes
+// of padding: ErrMessageTooLong is returned otherwise.
+func pkcs1v15Block(blockType byte, msg []byte, k int) (em []byte, ps []byte, err error) {
+	if len(msg) > k-11 {
+		return nil, nil, ErrMessageTooLong
+	}
+	em = make([]byte, k)
+	em[1] = blockType
+	copy(em[k-len(msg):], msg)
+	return em, em[2 : k-len(msg)-1], nil
+}
+
+// pad1 builds a type 1 block, used for signatures, where PS consists of 0xff bytes
+func pad1(msg []byte, k int) ([]byte, error) {
+	em, ps, err := pkcs1v15Block(1, msg, k)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ps {
+		ps[i] = 0xff
+	}
+	return em, nil
+}
+
+// pad2 builds a type 2 block, used for encryption, where PS consists of random non-zero bytes
+//
+// The random bytes are read from rand, which should be a CSPRNG.
+func pad2(rand io.Reader, msg []byte, k int) ([]byte, error) {
+	em, ps, err := pkcs1v15Block(2, msg, k)
+	if err != nil {
+		return nil, err
+	}
+	if err := nonZeroRandomBytes(ps, rand); err != nil {
+		return nil, err
+	}
+	return em, nil
 }
 
 // nonZeroRandomBytes fills the given slice with non-zero random octets.
@@ -233,20 +277,10 @@ func SignPKCS1v15(rand io.Reader, priv *PrivateKey, hash crypto.Hash, hashed []b
 		return nil, err
 	}
 
-	tLen := len(prefix) + hashLen
-	k := priv.Size()
-	if k < tLen+11 {
-		return nil, ErrMessageTooLong
-	}
-
-	// EM = 0x00 || 0x01 || PS || 0x00 || T
-	em := make([]byte, k)
-	em[1] = 1
-	for i := 2; i < k-tLen-1; i++ {
-		em[i] = 0xff
+	em, err := emsaPKCS1v15Encode(hashLen, prefix, hashed, priv.Size())
+	if err != nil {
+		return nil, err
 	}
-	copy(em[k-tLen:k-hashLen], prefix)
-	copy(em[k-hashLen:k], hashed)
 
 	m := natFromBytes(em)
 	c, err := decryptAndCheck(rand, priv, m)
@@ -263,14 +297,17 @@ func SignPKCS1v15(rand io.Reader, priv *PrivateKey, hash crypto.Hash, hashed []b
 // returning a nil error. If hash is zero then hashed is used directly. This
 // isn't advisable except for interoperability.
 func VerifyPKCS1v15(pub *PublicKey, hash crypto.Hash, hashed []byte, sig []byte) error {
+	if err := checkPub(pub); err != nil {
+		return err
+	}
 	hashLen, prefix, err := pkcs1v15HashInfo(hash, len(hashed))
 	if err != nil {
 		return err
 	}
 
-	tLen := len(prefix) + hashLen
 	k := pub.Size()
-	if k < tLen+11 {
+	expected, err := emsaPKCS1v15Encode(hashLen, prefix, hashed, k)
+	if err != nil {
 		return ErrVerification
 	}
 
@@ -281,26 +318,76 @@ func VerifyPKCS1v15(pub *PublicKey, hash crypto.Hash, hashed []byte, sig []byte)
 		return ErrVerification
 	}
 
-	c := natFromBytes(sig)
-	m := encrypt(new(nat), pub, c)
-	em := m.fillBytes(make([]byte, k))
-	// EM = 0x00 || 0x01 || PS || 0x00 || T
+	return verifyPKCS1v15(pub, modulusFromNat(natFromBig(pub.N)), expected, sig)
+}
 
-	ok := subtle.ConstantTimeByteEq(em[0], 0)
-	ok &= subtle.ConstantTimeByteEq(em[1], 1)
-	ok &= subtle.ConstantTimeCompare(em[k-hashLen:k], hashed)
-	ok &= subtle.ConstantTimeCompare(em[k-tLen:k-hashLen], prefix)
-	ok &= subtle.ConstantTimeByteEq(em[k-tLen-1], 0)
+// verifyPKCS1v15 checks that sig^e mod N matches the expected encoded message
+//
+// The signature should already have the same length as the modulus. The range
+// check on sig, and the comparison with the expected message, are accumulated
+// into a single choice, which only gets converted into an error at the very end.
+func verifyPKCS1v15(pub *PublicKey, nModulus *modulus, expected []byte, sig []byte) error {
+	c, valid := natFromBytesInRange(sig, nModulus)
+	m := encryptWithModulus(new(nat), pub, nModulus, c)
+	em := m.fillBytes(make([]byte, len(sig)))
+
+	// The encoding is deterministic, so we can check the entire padding at once
+	valid &= ctBytesEq(em, expected)
+	return errorUnless(valid, ErrVerification)
+}
 
-	for i := 2; i < k-tLen-1; i++ {
-		ok &= subtle.ConstantTimeByteEq(em[i], 0xff)
+// errorUnless returns nil if valid is 1, and err otherwise
+//
+// This is the only place where a choice about secret-dependent checks becomes a
+// branch, so callers should accumulate all of their checks before calling it.
+func errorUnless(valid choice, err error) error {
+	if valid != 1 {
+		return err
 	}
+	return nil
+}
 
-	if ok != 1 {
-		return ErrVerification
+// SignPKCS1v15 calculates the signature of hashed using RSASSA-PKCS1-V1_5-SIGN.
+//
+// This is the same as calling SignPKCS1v15 with this key.
+func (priv *PrivateKey) SignPKCS1v15(rand io.Reader, hash crypto.Hash, hashed []byte) ([]byte, error) {
+	return SignPKCS1v15(rand, priv, hash, hashed)
+}
+
+// VerifyPKCS1v15 verifies an RSA PKCS #1 v1.5 signature.
+//
+// This is the same as calling VerifyPKCS1v15 with this key.
+func (pub *PublicKey) VerifyPKCS1v15(hash crypto.Hash, hashed []byte, sig []byte) error {
+	return VerifyPKCS1v15(pub, hash, hashed, sig)
+}
+
+var errStreamHash = errors.New("crypto/rsa: unavailable hash function for streaming verification")
+
+// VerifyStream verifies an RSA PKCS #1 v1.5 signature over a message read from msg
+//
+// The message gets hashed incrementally, as it's read, so large messages don't need
+// to be held in memory. The resulting digest is then checked like VerifyPKCS1v15.
+// Unlike VerifyPKCS1v15, hash can't be 0, since the message has to be hashed.
+func (pub *PublicKey) VerifyStream(hash crypto.Hash, msg io.Reader, sig []byte) error {
+	if hash == 0 || !hash.Available() {
+		return errStreamHash
+	}
+	h := hash.New()
+	if _, err := io.Copy(h, msg); err != nil {
+		return err
 	}
+	return VerifyPKCS1v15(pub, hash, h.Sum(nil), sig)
+}
 
-	return nil
+// emsaPKCS1v15Encode builds the EMSA-PKCS1-v1_5 encoding of hashed, for a modulus of k bytes
+//
+// The encoding is EM = 0x00 || 0x01 || PS || 0x00 || T, where T is the DigestInfo
+// prefix followed by the hash, and PS is a string of at least 8 0xff bytes.
+func emsaPKCS1v15Encode(hashLen int, prefix []byte, hashed []byte, k int) ([]byte, error) {
+	t := make([]byte, len(prefix)+hashLen)
+	copy(t, prefix)
+	copy(t[len(prefix):], hashed)
+	return pad1(t, k)
 }
 
 func pkcs1v15HashInfo(hash crypto.Hash, inLen int) (hashLen int, prefix []byte, err error) {
@@ -8,13 +8,17 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
+	_ "crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"io"
 	"math/big"
 	"testing"
+	"testing/iotest"
 	"testing/quick"
 )
 
@@ -225,6 +229,103 @@ func TestVerifyPKCS1v15(t *testing.T) {
 	}
 }
 
+func TestEMSAPKCS1v15Encode(t *testing.T) {
+	// These are the DigestInfo prefixes given in RFC 8017, Section 9.2
+	prefixes := map[crypto.Hash]string{
+		crypto.SHA256: "3031300d060960864801650304020105000420",
+		crypto.SHA384: "3041300d060960864801650304020205000430",
+		crypto.SHA512: "3051300d060960864801650304020305000440",
+	}
+	for hash, prefixHex := range prefixes {
+		hashed := bytes.Repeat([]byte{0xAB}, hash.Size())
+		hashLen, prefix, err := pkcs1v15HashInfo(hash, len(hashed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedPrefix, _ := hex.DecodeString(prefixHex)
+		tLen := len(expectedPrefix) + hash.Size()
+		// The smallest size allowed has exactly 8 bytes of padding
+		for _, k := range []int{tLen + 11, tLen + 12, 256} {
+			em, err := emsaPKCS1v15Encode(hashLen, prefix, hashed, k)
+			if err != nil {
+				t.Errorf("%v, %d: %s", hash, k, err)
+				continue
+			}
+			expected := []byte{0x00, 0x01}
+			expected = append(expected, bytes.Repeat([]byte{0xFF}, k-tLen-3)...)
+			expected = append(expected, 0x00)
+			expected = append(expected, expectedPrefix...)
+			expected = append(expected, hashed...)
+			if !bytes.Equal(em, expected) {
+				t.Errorf("%v, %d: got %x, want %x", hash, k, em, expected)
+			}
+		}
+		if _, err := emsaPKCS1v15Encode(hashLen, prefix, hashed, tLen+10); err != ErrMessageTooLong {
+			t.Errorf("%v: expected ErrMessageTooLong, got %v", hash, err)
+		}
+	}
+}
+
+func TestSignPKCS1v15Interop(t *testing.T) {
+	std := &rsa.PublicKey{N: test2048Key.N, E: test2048Key.E}
+	for _, hash := range []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		h := hash.New()
+		h.Write([]byte("testing"))
+		hashed := h.Sum(nil)
+
+		sig, err := test2048Key.SignPKCS1v15(rand.Reader, hash, hashed)
+		if err != nil {
+			t.Errorf("%v: %s", hash, err)
+			continue
+		}
+		if err := rsa.VerifyPKCS1v15(std, hash, hashed, sig); err != nil {
+			t.Errorf("%v: crypto/rsa rejected signature: %s", hash, err)
+		}
+		if err := test2048Key.PublicKey.VerifyPKCS1v15(hash, hashed, sig); err != nil {
+			t.Errorf("%v: %s", hash, err)
+		}
+		hashed[0] ^= 1
+		if err := test2048Key.PublicKey.VerifyPKCS1v15(hash, hashed, sig); err == nil {
+			t.Errorf("%v: verified signature for the wrong hash", hash)
+		}
+	}
+}
+
+func TestVerifyStream(t *testing.T) {
+	msg := bytes.Repeat([]byte("a large payload, read in many small chunks\n"), 1000)
+	hashed := sha256.Sum256(msg)
+	sig, err := test2048Key.SignPKCS1v15(rand.Reader, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &test2048Key.PublicKey
+	if err := pub.VerifyStream(crypto.SHA256, iotest.HalfReader(bytes.NewReader(msg)), sig); err != nil {
+		t.Errorf("valid signature: %s", err)
+	}
+	tampered := append([]byte{}, msg...)
+	tampered[len(tampered)/2] ^= 1
+	if err := pub.VerifyStream(crypto.SHA256, iotest.OneByteReader(bytes.NewReader(tampered)), sig); err != ErrVerification {
+		t.Errorf("tampered message: expected ErrVerification, got %v", err)
+	}
+	// Errors from the reader get returned as is
+	errRead := errors.New("read error")
+	failing := io.MultiReader(bytes.NewReader(msg[:100]), iotest.ErrReader(errRead))
+	if err := pub.VerifyStream(crypto.SHA256, failing, sig); err != errRead {
+		t.Errorf("expected %v, got %v", errRead, err)
+	}
+	if err := pub.VerifyStream(0, bytes.NewReader(msg), sig); err != errStreamHash {
+		t.Errorf("expected errStreamHash, got %v", err)
+	}
+}
+
+func TestSignPKCS1v15TooLong(t *testing.T) {
+	// The 512 bit test key is too small for a SHA-512 DigestInfo
+	hashed := make([]byte, crypto.SHA512.Size())
+	if _, err := SignPKCS1v15(nil, rsaPrivateKey, crypto.SHA512, hashed); err != ErrMessageTooLong {
+		t.Errorf("expected ErrMessageTooLong, got %v", err)
+	}
+}
+
 func TestOverlongMessagePKCS1v15(t *testing.T) {
 	ciphertext := decodeBase64("fjOVdirUzFoLlukv80dBllMLjXythIf22feqPrNo0YoIjzyzyoMFiLjAc/Y4krkeZ11XFThIrEvw\nkRiZcCq5ng==")
 	_, err := DecryptPKCS1v15(nil, rsaPrivateKey, ciphertext)
@@ -275,6 +376,39 @@ func TestShortSessionKey(t *testing.T) {
 	}
 }
 
+func TestPKCS1v15Unpad(t *testing.T) {
+	pad := func(first, second byte, ps int, msg string) []byte {
+		em := []byte{first, second}
+		em = append(em, bytes.Repeat([]byte{0x42}, ps)...)
+		em = append(em, 0)
+		return append(em, msg...)
+	}
+	examples := []struct {
+		name  string
+		em    []byte
+		index int
+	}{
+		{"valid", pad(0, 2, 8, "message"), 11},
+		{"valid with long PS", pad(0, 2, 20, "message"), 23},
+		{"valid empty message", pad(0, 2, 8, ""), 11},
+		{"nonzero first byte", pad(1, 2, 8, "message"), 0},
+		{"wrong block type", pad(0, 1, 8, "message"), 0},
+		{"short PS", pad(0, 2, 7, "message"), 0},
+		{"no separator", append([]byte{0, 2}, bytes.Repeat([]byte{0x42}, 16)...), 0},
+		{"all zero", make([]byte, 16), 0},
+	}
+	for _, example := range examples {
+		// Invalid paddings always report an index of 0
+		valid, index := pkcs1v15Unpad(example.em)
+		if expected := ctEq(uint(example.index), 0) ^ 1; valid != expected {
+			t.Errorf("%s: valid = %d", example.name, valid)
+		}
+		if index != example.index {
+			t.Errorf("%s: index %d != %d", example.name, index, example.index)
+		}
+	}
+}
+
 // In order to generate new test vectors you'll need the PEM form of this key (and s/TESTING/PRIVATE/):
 // -----BEGIN RSA TESTING KEY-----
 // MIIBOgIBAAJBALKZD0nEffqM1ACuak0bijtqE2QrI/KLADv7l3kK3ppMyCuLKoF0
@@ -314,3 +448,79 @@ func TestShortPKCS1v15Signature(t *testing.T) {
 		t.Fatal("VerifyPKCS1v15 accepted a truncated signature")
 	}
 }
+
+func TestVerifyPKCS1v15EveryByte(t *testing.T) {
+	pub := &rsaPrivateKey.PublicKey
+	nModulus := modulusFromNat(natFromBig(pub.N))
+	hashed := sha1.Sum([]byte("message"))
+	hashLen, prefix, err := pkcs1v15HashInfo(crypto.SHA1, len(hashed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := pub.Size()
+	expected, err := emsaPKCS1v15Encode(hashLen, prefix, hashed[:], k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, k)
+	if err := rsaPrivateKey.DecryptPrimitive(sig, expected); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyPKCS1v15(pub, nModulus, expected, sig); err != nil {
+		t.Fatalf("valid signature: %v", err)
+	}
+	// Each tampered message gets signed, so that only the comparison can reject it
+	for i := 0; i < k; i++ {
+		em := append([]byte{}, expected...)
+		em[i] ^= 0x80
+		if em[0] != 0 {
+			// This message is larger than the modulus, and can't be signed
+			continue
+		}
+		if err := rsaPrivateKey.DecryptPrimitive(sig, em); err != nil {
+			t.Fatal(err)
+		}
+		if err := verifyPKCS1v15(pub, nModulus, expected, sig); err != ErrVerification {
+			t.Errorf("byte %d: expected ErrVerification, got %v", i, err)
+		}
+	}
+	if err := verifyPKCS1v15(pub, nModulus, expected, pub.N.Bytes()); err != ErrVerification {
+		t.Errorf("out of range signature: expected ErrVerification, got %v", err)
+	}
+}
+
+func TestPad1Pad2(t *testing.T) {
+	pads := map[byte]func(msg []byte, k int) ([]byte, error){
+		1: pad1,
+		2: func(msg []byte, k int) ([]byte, error) {
+			return pad2(rand.Reader, msg, k)
+		},
+	}
+	for blockType, pad := range pads {
+		for _, example := range []struct{ msgLen, k int }{{0, 11}, {5, 16}, {53, 64}, {0, 256}, {245, 256}} {
+			msg := make([]byte, example.msgLen)
+			for i := range msg {
+				msg[i] = byte(i)
+			}
+			em, err := pad(msg, example.k)
+			if err != nil {
+				t.Fatalf("type %d, %+v: %v", blockType, example, err)
+			}
+			psLen := example.k - example.msgLen - 3
+			if len(em) != example.k || em[0] != 0 || em[1] != blockType || em[2+psLen] != 0 || !bytes.Equal(em[3+psLen:], msg) {
+				t.Errorf("type %d, %+v: badly structured block %x", blockType, example, em)
+			}
+			for _, b := range em[2 : 2+psLen] {
+				if (blockType == 1 && b != 0xff) || b == 0 {
+					t.Errorf("type %d, %+v: bad padding %x", blockType, example, em[2:2+psLen])
+					break
+				}
+			}
+		}
+		for _, example := range []struct{ msgLen, k int }{{1, 11}, {0, 10}, {0, 0}, {54, 64}} {
+			if _, err := pad(make([]byte, example.msgLen), example.k); err != ErrMessageTooLong {
+				t.Errorf("type %d, %+v: expected ErrMessageTooLong, got %v", blockType, example, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package ctrsa
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// pkcs8PrivateKey reflects the ASN.1 structure of a PKCS #8 private key
+//
+// The optional attributes following the key are ignored.
+type pkcs8PrivateKey struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// oidRSAEncryption identifies RSA keys, as defined in RFC 8017, Appendix A.1
+var oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+var (
+	errPKCS8Version      = errors.New("crypto/rsa: unsupported PKCS #8 private key version")
+	errPKCS8NotRSA       = errors.New("crypto/rsa: PKCS #8 private key isn't an RSA key")
+	errPKCS8TrailingData = errors.New("crypto/rsa: trailing data after PKCS #8 key")
+)
+
+// ParsePKCS8PrivateKey parses an RSA private key in PKCS #8, ASN.1 DER form
+//
+// This has the form "PRIVATE KEY", as produced by x509.MarshalPKCS8PrivateKey.
+// The algorithm has to be rsaEncryption, and keys of other types are rejected.
+// The wrapped key is then parsed with ParsePKCS1PrivateKey, so it goes through
+// the same checks, and has its CRT values calculated if they're missing.
+func ParsePKCS8PrivateKey(der []byte) (*PrivateKey, error) {
+	var key pkcs8PrivateKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errPKCS8TrailingData
+	}
+	if key.Version != 0 {
+		return nil, errPKCS8Version
+	}
+	if !key.Algo.Algorithm.Equal(oidRSAEncryption) {
+		return nil, errPKCS8NotRSA
+	}
+	return ParsePKCS1PrivateKey(key.PrivateKey)
+}
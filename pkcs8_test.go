@@ -0,0 +1,76 @@
+package ctrsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestParsePKCS8PrivateKey(t *testing.T) {
+	for _, nprimes := range []int{2, 3} {
+		key, err := rsa.GenerateMultiPrimeKey(rand.Reader, nprimes, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		priv, err := ParsePKCS8PrivateKey(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.N.Cmp(key.N) != 0 || priv.E != key.E || priv.D.Cmp(key.D) != 0 {
+			t.Errorf("%d primes: parsed key doesn't match", nprimes)
+		}
+		testKeyBasics(t, priv)
+	}
+}
+
+func TestParsePKCS8PrivateKeyInvalid(t *testing.T) {
+	marshal := func(version int, oid asn1.ObjectIdentifier, inner []byte) []byte {
+		der, err := asn1.Marshal(pkcs8PrivateKey{
+			Version:    version,
+			Algo:       pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.NullRawValue},
+			PrivateKey: inner,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	inner := MarshalPKCS1PrivateKey(rsaPrivateKey)
+	if _, err := ParsePKCS8PrivateKey(marshal(0, oidRSAEncryption, inner)); err != nil {
+		t.Fatalf("unmodified key: %s", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePKCS8PrivateKey(ecDER); err != errPKCS8NotRSA {
+		t.Errorf("ECDSA key: expected errPKCS8NotRSA, got %v", err)
+	}
+
+	examples := map[string][]byte{
+		"version":       marshal(1, oidRSAEncryption, inner),
+		"other oid":     marshal(0, asn1.ObjectIdentifier{1, 3, 101, 112}, inner),
+		"inner garbage": marshal(0, oidRSAEncryption, []byte{0x30, 0x03, 0x02}),
+		"trailing data": append(marshal(0, oidRSAEncryption, inner), 0),
+		"garbage":       {0x30, 0x03, 0x02},
+	}
+	for name, der := range examples {
+		if _, err := ParsePKCS8PrivateKey(der); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
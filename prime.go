@@ -0,0 +1,353 @@
+package ctrsa
+
+import (
+	"errors"
+	"io"
+	"math/big"
+	"math/bits"
+	"math/rand"
+)
+
+// smallPrimesMask has bit i set exactly when i is a prime number, for i < 64
+const smallPrimesMask uint64 = 1<<2 | 1<<3 | 1<<5 | 1<<7 |
+	1<<11 | 1<<13 | 1<<17 | 1<<19 | 1<<23 | 1<<29 | 1<<31 |
+	1<<37 | 1<<41 | 1<<43 | 1<<47 | 1<<53 | 1<<59 | 1<<61
+
+// smallPrimesBound is the bound below which primes are used in smallFactor
+const smallPrimesBound = 2048
+
+// smallPrimeGroup is a group of small primes, along with their product
+type smallPrimeGroup struct {
+	product uint
+	primes  []uint
+}
+
+// smallPrimeGroups holds the odd primes below smallPrimesBound, with the product
+// of each group fitting over _W bits
+var smallPrimeGroups = makeSmallPrimeGroups()
+
+func makeSmallPrimeGroups() []smallPrimeGroup {
+	composite := make([]bool, smallPrimesBound)
+	var groups []smallPrimeGroup
+	group := smallPrimeGroup{product: 1}
+	for p := uint(3); p < smallPrimesBound; p += 2 {
+		if composite[p] {
+			continue
+		}
+		for q := p * p; q < smallPrimesBound; q += 2 * p {
+			composite[q] = true
+		}
+		if hi, lo := bits.Mul(group.product, p); hi != 0 || lo > _MASK {
+			groups = append(groups, group)
+			group = smallPrimeGroup{product: 1}
+		}
+		group.primes = append(group.primes, p)
+		group.product *= p
+	}
+	return append(groups, group)
+}
+
+// smallFactor returns an odd prime below smallPrimesBound dividing x, or 0 if there are none
+//
+// x itself is never returned, even if it's one of these small primes. This is
+// meant to sieve out candidates before the more expensive probablyPrime, and leaks
+// the value of x, which is fine for candidates that get rejected anyways.
+//
+// Instead of reducing x by each prime, we reduce it by the product of each group of primes,
+// and then check the remainder with regular division, needing only one modWord per group.
+func (x *nat) smallFactor() uint {
+	small := true
+	for i := 1; i < len(x.limbs); i++ {
+		small = small && x.limbs[i] == 0
+	}
+	for _, group := range smallPrimeGroups {
+		r := x.modWord(group.product)
+		for _, p := range group.primes {
+			if r%p == 0 && !(small && len(x.limbs) > 0 && x.limbs[0] == p) {
+				return p
+			}
+		}
+	}
+	return 0
+}
+
+// jacobi returns the Jacobi symbol (a/n), either -1, 0, or 1, like big.Jacobi
+//
+// n must be odd. This uses the binary variant of the reciprocity recurrence: factors
+// of 2 are removed from a, and then the larger of a and n gets reduced by the
+// smaller one, flipping the sign according to their residues modulo 8 and 4.
+//
+// This isn't constant-time at all, and is only meant for public values, such
+// as candidates during prime generation.
+func jacobi(a, n *nat) int {
+	a, n = alignedClones(a, n)
+	result := 1
+	for a.isZero() != 1 {
+		// (2/n) = -1 exactly when n = 3 or 5 mod 8
+		tz := a.trailingZeros()
+		a.shiftRight(tz)
+		if r := n.limbs[0] & 7; tz&1 == 1 && (r == 3 || r == 5) {
+			result = -result
+		}
+		// Both a and n are odd, so (a/n) = (n/a), unless both are 3 mod 4
+		if a.cmpLt(n) == 1 {
+			a, n = n, a
+			if a.limbs[0]&3 == 3 && n.limbs[0]&3 == 3 {
+				result = -result
+			}
+		}
+		a.sub(1, n)
+	}
+	// n now holds gcd(a, n), and the symbol is 0 unless they're coprime
+	if n.isOne() != 1 {
+		return 0
+	}
+	return result
+}
+
+// millerRabinRounds returns the default number of Miller-Rabin rounds for a random candidate of a given size
+//
+// For candidates chosen uniformly at random, like those of randomPrime, the bounds
+// of Damgård, Landrock, and Pomerance show that far fewer rounds than the worst-case
+// 4^-rounds suggests are needed, and fewer still as the size grows. These counts
+// are at least those in the FIPS 186 tables for RSA prime generation, and keep the
+// probability of accepting a composite below 2^-100. Below 256 bits, the worst-case
+// bound is used instead, which needs 50 rounds.
+func millerRabinRounds(bits int) int {
+	switch {
+	case bits >= 1536:
+		return 4
+	case bits >= 1024:
+		return 5
+	case bits >= 512:
+		return 8
+	case bits >= 256:
+		return 20
+	default:
+		return 50
+	}
+}
+
+// probablyPrime reports whether x is probably prime
+//
+// This performs rounds iterations of the Miller-Rabin test, the first of which
+// uses 2 as a base, and the rest pseudo-random bases. For any input, the
+// probability of a composite number being reported as prime is at most 4^-rounds.
+//
+// With rounds set to 0, or less, millerRabinRounds picks the number of rounds from the
+// size of x. That count is only sound for randomly chosen candidates: numbers
+// which might have been picked adversarially, like the primes of a key being
+// validated, need an explicit number of rounds.
+//
+// The size of x is leaked, as well as whether x is even, and the number of trailing
+// zeros in x - 1. Beyond that, the witness loop runs in constant time with respect
+// to the value of x, only returning early once x is known to be composite.
+func (x *nat) probablyPrime(rounds int) bool {
+	n := x.clone()
+	var size int
+	for size = len(n.limbs); size > 0 && n.limbs[size-1] == 0; size-- {
+	}
+	n.limbs = n.limbs[:size]
+	if size == 0 {
+		return false
+	}
+	// Small numbers don't leave us any room to choose a base, so we handle them directly
+	if size == 1 && n.limbs[0] < 64 {
+		return smallPrimesMask&(1<<n.limbs[0]) != 0
+	}
+	if n.limbs[0]&1 == 0 {
+		return false
+	}
+	m := modulusFromNat(n)
+	if rounds <= 0 {
+		rounds = millerRabinRounds(m.bitLen())
+	}
+
+	// Write n - 1 = 2^s * d, with d odd
+	nm1 := n.clone()
+	nm1.subWord(1)
+	s := nm1.trailingZeros()
+	d := nm1.clone().shiftRight(s).bytes(m)
+
+	// Like big.Int.ProbablyPrime, the bases are deterministic given the candidate
+	rng := rand.New(rand.NewSource(int64(n.limbs[0])))
+	baseBytes := make([]byte, len(d))
+	base := new(nat).expandFor(m)
+	y := new(nat).expandFor(m)
+	scratch := new(nat).expandFor(m)
+	for i := 0; i < rounds; i++ {
+		if i == 0 {
+			base.limbs[0] = 2
+		} else {
+			rng.Read(baseBytes)
+			base.mod(natFromBytes(baseBytes), m)
+		}
+		y.exp(base, d, m)
+		// A base of 0 would make every number look composite, so we let it pass.
+		// Bases of 1 and n - 1 pass by themselves, since d is odd.
+		passed := y.isOne() | y.cmpEq(nm1) | base.isZero()
+		for j := uint(1); j < s; j++ {
+			scratch.assign(1, y)
+			y.modMul(scratch, m)
+			passed |= y.cmpEq(nm1)
+		}
+		if passed != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// lucasPrime reports whether x passes the extra strong Lucas probable prime test
+//
+// Like big.Int.ProbablyPrime, this uses Baillie's parameters: Q = 1, and the first
+// P = 3, 4, 5, ... with ((P^2 - 4) / x) = -1. Writing x + 1 = 2^r * s, with s odd,
+// x passes if U_s = 0, and V_s = ±2, or if V_{2^t s} = 0, for some 0 <= t < r - 1.
+// The sequences only get evaluated modulo x, with a ladder over the bits of s.
+//
+// x must be odd, and larger than 63. The ladder runs in constant time, but the
+// search for P uses jacobi, which doesn't, and the final checks return early,
+// like the witness loop of probablyPrime does.
+func (x *nat) lucasPrime() bool {
+	n := x.clone()
+	var size int
+	for size = len(n.limbs); size > 0 && n.limbs[size-1] == 0; size-- {
+	}
+	n.limbs = n.limbs[:size]
+
+	var p uint
+	for p = 3; ; p++ {
+		j := jacobi(&nat{[]uint{p*p - 4}}, n)
+		if j == -1 {
+			break
+		}
+		if j == 0 {
+			// P^2 - 4 = (P - 2)(P + 2), which shares a factor with x, unless x = P + 2
+			return len(n.limbs) == 1 && n.limbs[0] == p+2
+		}
+		// Squares never give -1, so we'd search forever. This is rare enough
+		// that doing the check with big.Int, only after a while, is fine.
+		if p == 40 {
+			nBig := n.toBig()
+			root := new(big.Int).Sqrt(nBig)
+			if root.Mul(root, root).Cmp(nBig) == 0 {
+				return false
+			}
+		}
+	}
+	m := modulusFromNat(n)
+
+	// x + 1 might not fit in the limbs of x, so we leave room for it to grow
+	s := n.clone().expand(size + 1)
+	s.addWord(1)
+	r := s.trailingZeros()
+	s.shiftRight(r)
+
+	two := new(nat).expandFor(m)
+	two.limbs[0] = 2
+	twoMonty := two.toMonty(m)
+	pMonty := new(nat).mod(&nat{[]uint{p}}, m).toMonty(m)
+	minusTwo := newMontyNat(m)
+	(*nat)(minusTwo).modSub((*nat)(twoMonty), m)
+
+	// We maintain vk = V_k, and vk1 = V_{k + 1}, starting from V_0 = 2, and V_1 = P,
+	// using V_{2k} = V_k^2 - 2, and V_{2k + 1} = V_k V_{k + 1} - P.
+	vk := newMontyNat(m).set(twoMonty)
+	vk1 := newMontyNat(m).set(pMonty)
+	t := newMontyNat(m)
+	for i := len(s.limbs)*_W - 1; i >= 0; i-- {
+		bit := choice((s.limbs[i/_W] >> uint(i%_W)) & 1)
+		// If the bit is set, we update vk1 <- vk1^2 - 2, and vk <- vk vk1 - P instead
+		(*nat)(vk).swap(bit, (*nat)(vk1))
+		t.mul(vk, vk1, m)
+		(*nat)(vk1.set(t)).modSub((*nat)(pMonty), m)
+		t.mul(vk, vk, m)
+		(*nat)(vk.set(t)).modSub((*nat)(twoMonty), m)
+		(*nat)(vk).swap(bit, (*nat)(vk1))
+	}
+
+	if vk.equal(twoMonty)|vk.equal(minusTwo) == 1 {
+		// 2 V_{s + 1} - P V_s = (P^2 - 4) U_s, and P^2 - 4 is invertible modulo x
+		t.mul(vk, pMonty, m)
+		(*nat)(vk1).modAdd((*nat)(vk1).clone(), m)
+		if t.equal(vk1) == 1 {
+			return true
+		}
+	}
+	for i := uint(0); i+1 < r; i++ {
+		if (*nat)(vk).isZero() == 1 {
+			return true
+		}
+		if vk.equal(twoMonty) == 1 {
+			return false
+		}
+		t.mul(vk, vk, m)
+		(*nat)(vk.set(t)).modSub((*nat)(twoMonty), m)
+	}
+	return false
+}
+
+// bpswPrime reports whether x passes the Baillie-PSW test, like big.Int.ProbablyPrime(0)
+//
+// This is a single round of Miller-Rabin, with base 2, followed by lucasPrime.
+// No composite number passing both is known, and none exist below 2^64.
+func (x *nat) bpswPrime() bool {
+	if !x.probablyPrime(1) {
+		return false
+	}
+	// probablyPrime handles small numbers exactly, which lucasPrime doesn't accept
+	if x.bitLen() <= 6 {
+		return true
+	}
+	return x.lucasPrime()
+}
+
+// randomPrime returns a prime of exactly the given number of bits, using the random source random
+//
+// Like crypto/rand.Prime, the top two bits of the prime are set, so that the product of two
+// such primes has exactly twice as many bits. The primality test is done with nat.bpswPrime,
+// followed by the Miller-Rabin rounds of nat.probablyPrime.
+func randomPrime(random io.Reader, bits int) (*big.Int, error) {
+	if bits < 2 {
+		return nil, errors.New("crypto/rsa: prime size must be at least 2-bit")
+	}
+
+	b := uint(bits % 8)
+	if b == 0 {
+		b = 8
+	}
+	bytes := make([]byte, (bits+7)/8)
+	for {
+		if _, err := io.ReadFull(random, bytes); err != nil {
+			return nil, err
+		}
+		// Clear bits in the first byte to make sure the candidate has a size <= bits.
+		bytes[0] &= uint8(int(1<<b) - 1)
+		// Don't let the value be too small, i.e, set the most significant two bits.
+		// Setting the top two bits, rather than just the top bit,
+		// means that when two of these values are multiplied together,
+		// the result isn't ever one bit short.
+		if b >= 2 {
+			bytes[0] |= 3 << (b - 2)
+		} else {
+			// Here b==1, because b cannot be zero.
+			bytes[0] |= 1
+			if len(bytes) > 1 {
+				bytes[1] |= 0x80
+			}
+		}
+		// Make the value odd since an even number this large certainly isn't prime.
+		bytes[len(bytes)-1] |= 1
+
+		candidate := natFromBytes(bytes)
+		// Most composite candidates have a small factor, which is cheaper to find
+		if candidate.smallFactor() != 0 {
+			continue
+		}
+		// Like big.Int.ProbablyPrime, the Miller-Rabin rounds complement Baillie-PSW.
+		// Composite candidates almost always fail its first round, with base 2.
+		if candidate.bpswPrime() && candidate.probablyPrime(0) {
+			return new(big.Int).SetBytes(bytes), nil
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package ctrsa
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestProbablyPrimeSmall(t *testing.T) {
+	for i := int64(0); i < 2048; i++ {
+		x := big.NewInt(i)
+		expected := x.ProbablyPrime(20)
+		actual := natFromBig(x).probablyPrime(20)
+		if actual != expected {
+			t.Errorf("%d: %v != %v", i, actual, expected)
+		}
+	}
+}
+
+func TestProbablyPrimeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 3000; i++ {
+		bits := 8 + r.Intn(256)
+		x := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+		x.SetBit(x, 0, 1)
+		expected := x.ProbablyPrime(20)
+		actual := natFromBig(x).probablyPrime(20)
+		if actual != expected {
+			t.Errorf("%d: %v != %v", x, actual, expected)
+		}
+	}
+}
+
+func TestProbablyPrimeExamples(t *testing.T) {
+	primes := []string{
+		"2305843009213693951",
+		"170141183460469231731687303715884105727",
+		"6864797660130609714981900799081393217269435300143305409394463459185543183397656052122559640661454554977296311391480858037121987999716643812574028291115057151",
+		// Prime factor of a 2048 bit test key
+		"98920366548084643601728869055592650835572950932266967461790948584315647051443",
+	}
+	for _, s := range primes {
+		x, _ := new(big.Int).SetString(s, 10)
+		if !natFromBig(x).probablyPrime(20) {
+			t.Errorf("%s should be prime", s)
+		}
+	}
+	composites := []string{
+		// Carmichael numbers
+		"561",
+		"1105",
+		"1729",
+		"2465",
+		"2821",
+		"6601",
+		"8911",
+		"41041",
+		"825265",
+		"321197185",
+		"5394826801",
+		"232250619601",
+		"9746347772161",
+		// Strong pseudoprimes to base 2
+		"2047",
+		"3277",
+		"4033",
+		"3215031751",
+		"3825123056546413051",
+		// A product of two large primes
+		"21284175091214687912771199898307297748211672914763848041968395774954376176754",
+		"6084766654921918907427900243509372380954290099172559290432744450051395395951",
+	}
+	for _, s := range composites {
+		x, _ := new(big.Int).SetString(s, 10)
+		if natFromBig(x).probablyPrime(20) {
+			t.Errorf("%s should be composite", s)
+		}
+	}
+}
+
+func TestMillerRabinRounds(t *testing.T) {
+	for bits, expected := range map[int]int{64: 50, 256: 20, 512: 8, 1024: 5, 1536: 4, 2048: 4} {
+		if actual := millerRabinRounds(bits); actual != expected {
+			t.Errorf("%d bits: %d rounds != %d", bits, actual, expected)
+		}
+	}
+	// The default rounds still accept primes, and reject composites, of every size
+	for _, bits := range []int{512, 1024, 2048} {
+		p, err := crand.Prime(crand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !natFromBig(p).probablyPrime(0) {
+			t.Errorf("%d bits: %v should be prime", bits, p)
+		}
+		// A product of two primes has no small factors to give it away
+		q1, err := crand.Prime(crand.Reader, bits/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q2, err := crand.Prime(crand.Reader, bits/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		composite := new(big.Int).Mul(q1, q2)
+		if natFromBig(composite).probablyPrime(0) {
+			t.Errorf("%d bits: %v should be composite", bits, composite)
+		}
+	}
+}
+
+func TestLucasPrime(t *testing.T) {
+	// Extra strong Lucas pseudoprimes, which only Miller-Rabin catches
+	for _, x := range []uint{989, 3239, 5777, 10877, 27971, 29681, 30739, 31631, 39059} {
+		if !(&nat{[]uint{x}}).lucasPrime() {
+			t.Errorf("%d should pass the Lucas test", x)
+		}
+	}
+	// Strong pseudoprimes to base 2, which only the Lucas test catches
+	for _, x := range []uint{2047, 3277, 4033, 4681, 8321, 15841, 29341, 42799, 49141} {
+		if (&nat{[]uint{x}}).lucasPrime() {
+			t.Errorf("%d shouldn't pass the Lucas test", x)
+		}
+		if !(&nat{[]uint{x}}).probablyPrime(1) || (&nat{[]uint{x}}).bpswPrime() {
+			t.Errorf("%d should only pass the Miller-Rabin test", x)
+		}
+	}
+}
+
+func TestBPSWPrime(t *testing.T) {
+	n := int64(100000)
+	if testing.Short() {
+		n = 10000
+	}
+	for i := int64(1); i < n; i += 2 {
+		x := big.NewInt(i)
+		if actual, expected := natFromBig(x).bpswPrime(), x.ProbablyPrime(0); actual != expected {
+			t.Errorf("%d: %v != %v", i, actual, expected)
+		}
+	}
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		x := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(8+r.Intn(512))))
+		x.SetBit(x, 0, 1)
+		// Odd squares exercise the search for P giving up
+		if i%10 == 0 {
+			x.Mul(x, x)
+		}
+		if actual, expected := natFromBig(x).bpswPrime(), x.ProbablyPrime(0); actual != expected {
+			t.Errorf("%d: %v != %v", x, actual, expected)
+		}
+	}
+	for _, bits := range []int{64, 512, 1024} {
+		p, err := crand.Prime(crand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !natFromBig(p).bpswPrime() {
+			t.Errorf("%v should be prime", p)
+		}
+	}
+}
+
+func TestSmallFactor(t *testing.T) {
+	for i := int64(0); i < 3*smallPrimesBound; i++ {
+		x := natFromBig(big.NewInt(i))
+		p := x.smallFactor()
+		if p == 0 {
+			// Only primes, powers of 2, and products of larger primes have no small odd factor
+			for q := int64(3); q < smallPrimesBound && q < i; q += 2 {
+				if i%q == 0 {
+					t.Errorf("%d: missed the factor %d", i, q)
+					break
+				}
+			}
+			continue
+		}
+		if int64(p) == i || i%int64(p) != 0 || !big.NewInt(int64(p)).ProbablyPrime(20) {
+			t.Errorf("%d: %d isn't a proper prime factor", i, p)
+		}
+	}
+	// Large values with a known small factor
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		q := new(big.Int).Rand(r, new(big.Int).Lsh(bigOne, 1024))
+		q.Mul(q, big.NewInt(2039))
+		if p := natFromBig(q).smallFactor(); p == 0 || new(big.Int).Mod(q, big.NewInt(int64(p))).Sign() != 0 {
+			t.Errorf("%v: %d isn't a factor", q, p)
+		}
+	}
+}
+
+func TestJacobi(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	check := func(a, n *big.Int) {
+		if actual, expected := jacobi(natFromBig(a), natFromBig(n)), big.Jacobi(a, n); actual != expected {
+			t.Errorf("(%v/%v): %d != %d", a, n, actual, expected)
+		}
+	}
+	for a := int64(0); a < 50; a++ {
+		for n := int64(1); n < 50; n += 2 {
+			check(big.NewInt(a), big.NewInt(n))
+		}
+	}
+	for i := 0; i < 500; i++ {
+		n := new(big.Int).Rand(r, new(big.Int).Lsh(bigOne, uint(8+r.Intn(512))))
+		n.SetBit(n, 0, 1)
+		a := new(big.Int).Rand(r, new(big.Int).Lsh(bigOne, uint(8+r.Intn(512))))
+		check(a, n)
+		// Shared factors give 0
+		check(new(big.Int).Mul(a, big.NewInt(3)), new(big.Int).Mul(n, big.NewInt(3)))
+	}
+}
+
+func TestRandomPrime(t *testing.T) {
+	for _, bits := range []int{2, 3, 7, 8, 9, 63, 64, 65, 127, 512} {
+		p, err := randomPrime(crand.Reader, bits)
+		if err != nil {
+			t.Errorf("%d: %s", bits, err)
+			continue
+		}
+		if p.BitLen() != bits {
+			t.Errorf("%d: prime has %d bits", bits, p.BitLen())
+		}
+		if p.Bit(bits-2) != 1 {
+			t.Errorf("%d: second highest bit of %d isn't set", bits, p)
+		}
+		if !p.ProbablyPrime(20) {
+			t.Errorf("%d: %d isn't prime", bits, p)
+		}
+	}
+}
+
+func TestRandomPrimeErrors(t *testing.T) {
+	if _, err := randomPrime(crand.Reader, 1); err == nil {
+		t.Errorf("randomPrime should reject primes of a single bit")
+	}
+	// Running out of randomness needs to be reported
+	if _, err := randomPrime(bytes.NewReader(make([]byte, 3)), 128); err == nil {
+		t.Errorf("randomPrime should fail on a short reader")
+	}
+}
+
+func BenchmarkProbablyPrime1024(b *testing.B) {
+	p, _ := new(big.Int).SetString("179769313486231590772930519078902473361797697894230657273430081157732675805500963132708477322407536021120113879871393357658789768814416622492847430639474124377767893424865485276302219601246094119453082952085005768838150682342462881473913110540827237163350510684586298239947245938479716304835356329624224137859", 10)
+	// Make sure we're benchmarking the full test, and not an early exit
+	for !p.ProbablyPrime(20) {
+		p.Add(p, big.NewInt(2))
+	}
+	x := natFromBig(p)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.probablyPrime(20)
+	}
+}
+
+func BenchmarkPrimeCandidates1024(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	candidates := make([]*nat, 64)
+	for i := range candidates {
+		c := new(big.Int).Rand(r, new(big.Int).Lsh(bigOne, 1024))
+		candidates[i] = natFromBig(c.SetBit(c, 0, 1).SetBit(c, 1023, 1))
+	}
+	// Each iteration tests all of the candidates, as randomPrime would, with and without sieving
+	b.Run("sieve", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, c := range candidates {
+				if c.smallFactor() == 0 {
+					c.probablyPrime(20)
+				}
+			}
+		}
+	})
+	b.Run("nosieve", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, c := range candidates {
+				c.probablyPrime(20)
+			}
+		}
+	})
+}
@@ -196,7 +196,7 @@ func emsaPSSVerify(mHash, em []byte, emBits, sLen int, hash hash.Hash) error {
 	h0 := hash.Sum(nil)
 
 	// 14. If H = H', output "consistent." Otherwise, output "inconsistent."
-	if !bytes.Equal(h0, h) { // TODO: constant time?
+	if ctBytesEq(h0, h) != 1 {
 		return ErrVerification
 	}
 	return nil
@@ -287,13 +287,27 @@ func SignPSS(rand io.Reader, priv *PrivateKey, hash crypto.Hash, digest []byte,
 // argument may be nil, in which case sensible defaults are used. opts.Hash is
 // ignored.
 func VerifyPSS(pub *PublicKey, hash crypto.Hash, digest []byte, sig []byte, opts *PSSOptions) error {
+	if err := checkPub(pub); err != nil {
+		return err
+	}
 	if len(sig) != pub.Size() {
 		return ErrVerification
 	}
-	s := natFromBytes(sig)
+	s, err := natFromBytesChecked(sig, modulusFromNat(natFromBig(pub.N)))
+	if err != nil {
+		return ErrVerification
+	}
 	m := encrypt(new(nat), pub, s)
 	emBits := pub.N.BitLen() - 1
 	emLen := (emBits + 7) / 8
-	em := m.fillBytes(make([]byte, emLen))
+	// When the modulus has 8k + 1 bits, EM is one byte shorter than the modulus,
+	// and that extra leading byte needs to be zero.
+	em := m.fillBytes(make([]byte, len(sig)))
+	if emLen < len(em) {
+		if em[0] != 0 {
+			return ErrVerification
+		}
+		em = em[1:]
+	}
 	return emsaPSSVerify(digest, em, emBits, opts.saltLength(), hash.New())
 }
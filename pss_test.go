@@ -11,6 +11,7 @@ import (
 	"crypto"
 	_ "crypto/md5"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
 	_ "crypto/sha256"
@@ -252,6 +253,53 @@ func TestSignWithPSSSaltLengthAuto(t *testing.T) {
 	}
 }
 
+func TestPSSInterop(t *testing.T) {
+	keys := []*PrivateKey{test2048Key}
+	if !testing.Short() {
+		// A key with 8k + 1 bits makes EM shorter than the modulus
+		oddKey, err := GenerateKey(rand.Reader, 1025)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, oddKey)
+	}
+	hash := crypto.SHA256
+	digest := sha256.Sum256([]byte("testing"))
+	for _, priv := range keys {
+		std := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: priv.N, E: priv.E},
+			D:         priv.D,
+			Primes:    priv.Primes,
+		}
+		std.Precompute()
+		for _, saltLength := range []int{PSSSaltLengthEqualsHash, PSSSaltLengthAuto} {
+			opts := &PSSOptions{SaltLength: saltLength}
+			stdOpts := &rsa.PSSOptions{SaltLength: saltLength}
+
+			sig, err := SignPSS(rand.Reader, priv, hash, digest[:], opts)
+			if err != nil {
+				t.Errorf("%d bits, salt length %d: %s", priv.N.BitLen(), saltLength, err)
+				continue
+			}
+			if err := rsa.VerifyPSS(&std.PublicKey, hash, digest[:], sig, stdOpts); err != nil {
+				t.Errorf("%d bits, salt length %d: crypto/rsa rejected signature: %s", priv.N.BitLen(), saltLength, err)
+			}
+
+			sig, err = rsa.SignPSS(rand.Reader, std, hash, digest[:], stdOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyPSS(&priv.PublicKey, hash, digest[:], sig, opts); err != nil {
+				t.Errorf("%d bits, salt length %d: rejected crypto/rsa signature: %s", priv.N.BitLen(), saltLength, err)
+			}
+			sig[len(sig)-1] ^= 1
+			if err := VerifyPSS(&priv.PublicKey, hash, digest[:], sig, opts); err == nil {
+				t.Errorf("%d bits, salt length %d: accepted a corrupted signature", priv.N.BitLen(), saltLength)
+			}
+		}
+	}
+}
+
 func bigFromHex(hex string) *big.Int {
 	n, ok := new(big.Int).SetString(hex, 16)
 	if !ok {
@@ -24,14 +24,11 @@ package ctrsa
 
 import (
 	"crypto"
-	"crypto/rand"
-	"crypto/subtle"
 	"errors"
 	"hash"
 	"io"
 	"math"
 	"math/big"
-	"math/bits"
 
 	"github.com/cronokirby/ctrsa/internal/randutil"
 )
@@ -54,12 +51,32 @@ func (pub *PublicKey) Size() int {
 }
 
 // Equal reports whether pub and x have the same value.
+//
+// This implements the Equal method expected of a crypto.PublicKey. Keys of other
+// types, including *rsa.PublicKey from the standard library, are never equal.
 func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
 	xx, ok := x.(*PublicKey)
-	if !ok {
+	if !ok || xx == nil {
 		return false
 	}
-	return pub.N.Cmp(xx.N) == 0 && pub.E == xx.E
+	if pub.E != xx.E {
+		return false
+	}
+	// This is the comparison done by modulus.equal, without needing montgomery constants
+	n, xn := alignedClones(natFromBig(pub.N), natFromBig(xx.N))
+	return n.cmpEq(xn) == 1
+}
+
+// NewPublicKey creates a public key from a big endian modulus n, and a public exponent e.
+func NewPublicKey(n []byte, e uint) (*PublicKey, error) {
+	if e > math.MaxInt32 {
+		return nil, errPublicExponentLarge
+	}
+	pub := &PublicKey{N: new(big.Int).SetBytes(n), E: int(e)}
+	if err := checkPub(pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
 }
 
 // OAEPOptions is an interface for passing options to OAEP decryption using the
@@ -72,10 +89,36 @@ type OAEPOptions struct {
 	Label []byte
 }
 
+// ErrInvalidKey is matched, with errors.Is, by all of the errors returned when a key
+// fails validation.
+//
+// The errors themselves describe which check failed, since keys aren't secret
+// inputs chosen by an attacker.
+var ErrInvalidKey = errors.New("crypto/rsa: invalid key")
+
+// keyError is a description of why a key was rejected
+//
+// Each keyError matches ErrInvalidKey, with errors.Is.
+type keyError string
+
+func (e keyError) Error() string {
+	return string(e)
+}
+
+func (keyError) Is(target error) bool {
+	return target == ErrInvalidKey
+}
+
 var (
-	errPublicModulus       = errors.New("crypto/rsa: missing public modulus")
-	errPublicExponentSmall = errors.New("crypto/rsa: public exponent too small")
-	errPublicExponentLarge = errors.New("crypto/rsa: public exponent too large")
+	errPublicModulus       = keyError("crypto/rsa: missing public modulus")
+	errPublicExponentSmall = keyError("crypto/rsa: public exponent too small")
+	errPublicExponentLarge = keyError("crypto/rsa: public exponent too large")
+	errPrivateExponent     = keyError("crypto/rsa: missing private exponent")
+	errTooFewPrimes        = keyError("crypto/rsa: a private key needs at least two primes")
+	errInvalidPrime        = keyError("crypto/rsa: invalid prime value")
+	errCompositePrime      = keyError("crypto/rsa: composite prime value")
+	errInvalidModulus      = keyError("crypto/rsa: invalid modulus")
+	errInvalidExponents    = keyError("crypto/rsa: invalid exponents")
 )
 
 // checkPub sanity checks the public key before we use it.
@@ -93,6 +136,12 @@ func checkPub(pub *PublicKey) error {
 	if pub.E > 1<<31-1 {
 		return errPublicExponentLarge
 	}
+	if pub.N.Sign() <= 0 {
+		return errModulusZero
+	}
+	if pub.N.Bit(0) != 1 {
+		return errModulusEven
+	}
 	return nil
 }
 
@@ -108,10 +157,27 @@ type PrivateKey struct {
 }
 
 // Public returns the public key corresponding to priv.
+//
+// The result is always a *PublicKey. If priv is missing its modulus, like a key
+// assembled by hand from its primes, N is reconstructed as the product of the primes.
+// Public never modifies priv, so it's safe to call concurrently; Precompute stores
+// that product in priv, so later calls don't recompute it.
 func (priv *PrivateKey) Public() crypto.PublicKey {
+	if priv.N == nil && len(priv.Primes) >= 2 {
+		return &PublicKey{N: productOfPrimes(priv.Primes), E: priv.E}
+	}
 	return &priv.PublicKey
 }
 
+// productOfPrimes returns the product of all the primes, i.e. the modulus of a key
+func productOfPrimes(primes []*big.Int) *big.Int {
+	n := new(big.Int).Set(bigOne)
+	for _, prime := range primes {
+		n.Mul(n, prime)
+	}
+	return n
+}
+
 // Equal reports whether priv and x have equivalent values. It ignores
 // Precomputed values.
 func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
@@ -200,6 +266,10 @@ type CRTValue struct {
 
 // Validate performs basic sanity checks on the key.
 // It returns nil if the key is valid, or else an error describing a problem.
+//
+// Beyond the checks done by crypto/rsa, this also checks that each prime is
+// probably prime, and that the precomputed CRT values, if present, are consistent
+// with the rest of the key.
 func (priv *PrivateKey) Validate() error {
 	if err := checkPub(&priv.PublicKey); err != nil {
 		return err
@@ -210,12 +280,15 @@ func (priv *PrivateKey) Validate() error {
 	for _, prime := range priv.Primes {
 		// Any primes ≤ 1 will cause divide-by-zero panics later.
 		if prime.Cmp(bigOne) <= 0 {
-			return errors.New("crypto/rsa: invalid prime value")
+			return errInvalidPrime
+		}
+		if !natFromBig(prime).probablyPrime(20) {
+			return errCompositePrime
 		}
 		modulus.Mul(modulus, prime)
 	}
 	if modulus.Cmp(priv.N) != 0 {
-		return errors.New("crypto/rsa: invalid modulus")
+		return errInvalidModulus
 	}
 
 	// Check that de ≡ 1 mod p-1, for each prime.
@@ -230,18 +303,89 @@ func (priv *PrivateKey) Validate() error {
 		pminus1 := new(big.Int).Sub(prime, bigOne)
 		congruence.Mod(de, pminus1)
 		if congruence.Cmp(bigOne) != 0 {
-			return errors.New("crypto/rsa: invalid exponents")
+			return errInvalidExponents
 		}
 	}
+
+	if priv.Precomputed.Dp == nil && priv.Precomputed.Dq == nil && priv.Precomputed.Qinv == nil && len(priv.Precomputed.CRTValues) == 0 {
+		return nil
+	}
+	if !precomputedConsistent(priv) {
+		return errPrecomputed
+	}
 	return nil
 }
 
+var errPrecomputed = keyError("crypto/rsa: invalid CRT values")
+
+// precomputedConsistent checks that the CRT values of a key match its primes and private exponent
+//
+// Like Validate, this uses big.Int, and isn't constant-time.
+func precomputedConsistent(priv *PrivateKey) bool {
+	if len(priv.Primes) < 2 || len(priv.Precomputed.CRTValues) != len(priv.Primes)-2 {
+		return false
+	}
+	if priv.Precomputed.Dp == nil || priv.Precomputed.Dq == nil || priv.Precomputed.Qinv == nil {
+		return false
+	}
+	expMatches := func(exp, prime *big.Int) bool {
+		pminus1 := new(big.Int).Sub(prime, bigOne)
+		return exp != nil && exp.Cmp(new(big.Int).Mod(priv.D, pminus1)) == 0
+	}
+	inverseMatches := func(coeff, r, prime *big.Int) bool {
+		if coeff == nil || coeff.Sign() < 0 || coeff.Cmp(prime) >= 0 {
+			return false
+		}
+		product := new(big.Int).Mul(coeff, r)
+		return product.Mod(product, prime).Cmp(bigOne) == 0
+	}
+
+	p, q := priv.Primes[0], priv.Primes[1]
+	if !expMatches(priv.Precomputed.Dp, p) || !expMatches(priv.Precomputed.Dq, q) {
+		return false
+	}
+	if !inverseMatches(priv.Precomputed.Qinv, q, p) {
+		return false
+	}
+	r := new(big.Int).Mul(p, q)
+	for i, values := range priv.Precomputed.CRTValues {
+		prime := priv.Primes[2+i]
+		// R is the product of the previous primes
+		if values.R == nil || values.R.Cmp(r) != 0 {
+			return false
+		}
+		if !expMatches(values.Exp, prime) || !inverseMatches(values.Coeff, values.R, prime) {
+			return false
+		}
+		r.Mul(r, prime)
+	}
+	return true
+}
+
 // GenerateKey generates an RSA keypair of the given bit size using the
 // random source random (for example, crypto/rand.Reader).
 func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
 	return GenerateMultiPrimeKey(random, 2, bits)
 }
 
+var errGenerateExponent = errors.New("crypto/rsa: public exponent must be odd, and larger than 1")
+
+// GenerateKeyWithExponent is like GenerateKey, with a chosen public exponent
+//
+// The exponent must be odd, larger than 1, and fit in 31 bits, like the exponents
+// accepted by the other functions in this package. Primes p where e shares a
+// factor with p - 1 are rejected, so small exponents, like 3, make generation slower.
+func GenerateKeyWithExponent(random io.Reader, bits int, e int) (*PrivateKey, error) {
+	if e < 3 || e%2 == 0 {
+		return nil, errGenerateExponent
+	}
+	if e > 1<<31-1 {
+		return nil, errPublicExponentLarge
+	}
+	randutil.MaybeReadByte(random)
+	return generateMultiPrimeKey(random, 2, bits, e)
+}
+
 // GenerateMultiPrimeKey generates a multi-prime RSA keypair of the given bit
 // size and the given random source, as suggested in [1]. Although the public
 // keys are compatible (actually, indistinguishable) from the 2-prime case,
@@ -255,9 +399,21 @@ func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
 // [2] http://www.cacr.math.uwaterloo.ca/techreports/2006/cacr2006-16.pdf
 func GenerateMultiPrimeKey(random io.Reader, nprimes int, bits int) (*PrivateKey, error) {
 	randutil.MaybeReadByte(random)
+	return generateMultiPrimeKey(random, nprimes, bits, 65537)
+}
 
+// generateMultiPrimeKey is GenerateMultiPrimeKey, without the call to MaybeReadByte
+//
+// The key only depends on the bytes read from random: the same stream of bytes
+// always produces the same key. The exported functions deliberately break this,
+// so that callers don't come to rely on it, but tests can use it to catch
+// regressions in the generation logic. The public exponent e must already have
+// been checked to be odd, and larger than 1.
+func generateMultiPrimeKey(random io.Reader, nprimes int, bits int, e int) (*PrivateKey, error) {
 	priv := new(PrivateKey)
-	priv.E = 65537
+	priv.E = e
+	eNat := natFromBig(big.NewInt(int64(e)))
+	pminus1 := new(big.Int)
 
 	if nprimes < 2 {
 		return nil, errors.New("crypto/rsa: GenerateMultiPrimeKey: nprimes must be >= 2")
@@ -298,18 +454,28 @@ NextSetOfPrimes:
 			todo += (nprimes - 2) / 5
 		}
 		for i := 0; i < nprimes; i++ {
-			var err error
-			primes[i], err = rand.Prime(random, todo/(nprimes-i))
-			if err != nil {
-				return nil, err
+			// e needs to be invertible modulo p - 1, otherwise we can't find d,
+			// so we might as well look for a different prime right away.
+			for {
+				var err error
+				primes[i], err = randomPrime(random, todo/(nprimes-i))
+				if err != nil {
+					return nil, err
+				}
+				pminus1.Sub(primes[i], bigOne)
+				if gcd(eNat, natFromBig(pminus1)).isOne() == 1 {
+					break
+				}
 			}
 			todo -= primes[i].BitLen()
 		}
 
 		// Make sure that primes is pairwise unequal.
+		nats := make([]*nat, len(primes))
 		for i, prime := range primes {
+			nats[i] = natFromBig(prime)
 			for j := 0; j < i; j++ {
-				if prime.Cmp(primes[j]) == 0 {
+				if x, y := alignedClones(nats[i], nats[j]); x.cmpEq(y) == 1 {
 					continue NextSetOfPrimes
 				}
 			}
@@ -317,7 +483,6 @@ NextSetOfPrimes:
 
 		n := new(big.Int).Set(bigOne)
 		totient := new(big.Int).Set(bigOne)
-		pminus1 := new(big.Int)
 		for _, prime := range primes {
 			n.Mul(n, prime)
 			pminus1.Sub(prime, bigOne)
@@ -330,11 +495,9 @@ NextSetOfPrimes:
 			continue NextSetOfPrimes
 		}
 
-		priv.D = new(big.Int)
-		e := big.NewInt(int64(priv.E))
-		ok := priv.D.ModInverse(e, totient)
-
-		if ok != nil {
+		d, err := modInverseEven(uint(priv.E), natFromBig(totient))
+		if err == nil {
+			priv.D = d.toBig()
 			priv.Primes = primes
 			priv.N = n
 			break
@@ -362,22 +525,11 @@ func incCounter(c *[4]byte) {
 // mgf1XOR XORs the bytes in out with a mask generated using the MGF1 function
 // specified in PKCS #1 v2.1.
 func mgf1XOR(out []byte, hash hash.Hash, seed []byte) {
-	var counter [4]byte
-	var digest []byte
-
-	done := 0
-	for done < len(out) {
-		hash.Write(seed)
-		hash.Write(counter[0:4])
-		digest = hash.Sum(digest[:0])
-		hash.Reset()
-
-		for i := 0; i < len(digest) && done < len(out); i++ {
-			out[done] ^= digest[i]
-			done++
-		}
-		incCounter(&counter)
+	mask := mgf1(hash, seed, len(out))
+	for i := range out {
+		out[i] ^= mask[i]
 	}
+	hash.Reset()
 }
 
 // ErrMessageTooLong is returned when attempting to encrypt a message which is
@@ -385,19 +537,45 @@ func mgf1XOR(out []byte, hash hash.Hash, seed []byte) {
 var ErrMessageTooLong = errors.New("crypto/rsa: message too long for RSA public key size")
 
 func encrypt(c *nat, pub *PublicKey, m *nat) *nat {
-	nModulus := modulusFromNat(natFromBig(pub.N))
+	return encryptWithModulus(c, pub, modulusFromNat(natFromBig(pub.N)), m)
+}
+
+// encryptWithModulus is like encrypt, using an already prepared modulus for pub.N
+//
+// nModulus is only read, so it can be shared between multiple calls, running concurrently.
+func encryptWithModulus(c *nat, pub *PublicKey, nModulus *modulus, m *nat) *nat {
 	m = m.clone().expandFor(nModulus)
 
 	// This calculation leaks information about e, but it's public, so this is ok
-	e64 := uint64(pub.E)
-	eBytes := (bits.Len64(e64) + 7) / 8
-	e := make([]byte, eBytes)
-	for i := len(e) - 1; i >= 0; i-- {
-		e[i] = byte(e64)
-		e64 >>= 8
-	}
+	return c.expShort(m, uint(pub.E), nModulus)
+}
 
-	return c.exp(m, e, nModulus)
+var errMessageRepresentative = errors.New("crypto/rsa: message representative out of range")
+
+// EncryptPrimitive computes the raw RSA operation m^e mod N, without any padding.
+//
+// Both m and out are big endian, and out must be exactly pub.Size() bytes long.
+// An error is returned if m is not strictly smaller than the modulus.
+//
+// WARNING: raw RSA is not secure on its own. This is only intended to build
+// padding schemes on top of.
+func (pub *PublicKey) EncryptPrimitive(out, m []byte) error {
+	if err := checkPub(pub); err != nil {
+		return err
+	}
+	k := pub.Size()
+	if len(out) != k {
+		return errors.New("crypto/rsa: output buffer has the wrong size")
+	}
+	if len(m) > k {
+		return errMessageRepresentative
+	}
+	x, err := natFromBytesChecked(m, modulusFromNat(natFromBig(pub.N)))
+	if err != nil {
+		return errMessageRepresentative
+	}
+	encrypt(new(nat), pub, x).fillBytes(out)
+	return nil
 }
 
 // EncryptOAEP encrypts the given message with RSA-OAEP.
@@ -464,18 +642,23 @@ var ErrVerification = errors.New("crypto/rsa: verification error")
 
 // Precompute performs some calculations that speed up private key operations
 // in the future.
+//
+// A missing modulus gets filled in with the product of the primes.
 func (priv *PrivateKey) Precompute() {
+	if priv.N == nil && len(priv.Primes) >= 2 {
+		priv.N = productOfPrimes(priv.Primes)
+	}
 	if priv.Precomputed.Dp != nil {
 		return
 	}
 
-	priv.Precomputed.Dp = new(big.Int).Sub(priv.Primes[0], bigOne)
-	priv.Precomputed.Dp.Mod(priv.D, priv.Precomputed.Dp)
-
-	priv.Precomputed.Dq = new(big.Int).Sub(priv.Primes[1], bigOne)
-	priv.Precomputed.Dq.Mod(priv.D, priv.Precomputed.Dq)
-
-	priv.Precomputed.Qinv = new(big.Int).ModInverse(priv.Primes[1], priv.Primes[0])
+	dp, dq, qinv, err := computeCRTParams(natFromBig(priv.D), natFromBig(priv.Primes[0]), natFromBig(priv.Primes[1]))
+	if err != nil {
+		return
+	}
+	priv.Precomputed.Dp = dp.toBig()
+	priv.Precomputed.Dq = dq.toBig()
+	priv.Precomputed.Qinv = qinv.toBig()
 
 	r := new(big.Int).Mul(priv.Primes[0], priv.Primes[1])
 	priv.Precomputed.CRTValues = make([]CRTValue, len(priv.Primes)-2)
@@ -493,6 +676,68 @@ func (priv *PrivateKey) Precompute() {
 	}
 }
 
+// computeCRTParams derives dp = d mod (p - 1), dq = d mod (q - 1), and qinv = q^-1 mod p
+//
+// These are the CRT values of a two prime key, which only stores d, p, and q. p - 1
+// and q - 1 are even, so Montgomery multiplication can't use them as moduli, but
+// mod only shifts limbs in, which works for any nonzero modulus. The reductions
+// and the inverse don't leak the values of d, p, or q, beyond their announced lengths.
+//
+// An error is returned if p or q is smaller than 3, even, or if q isn't invertible modulo p.
+func computeCRTParams(d, p, q *nat) (dp, dq, qinv *nat, err error) {
+	pModulus, err := modulusFromNatChecked(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := modulusFromNatChecked(q); err != nil {
+		return nil, nil, nil, err
+	}
+	pMinus1, qMinus1 := p.clone(), q.clone()
+	pMinus1.subWord(1)
+	qMinus1.subWord(1)
+	if pMinus1.isZero()|qMinus1.isZero() == 1 {
+		return nil, nil, nil, errInvalidPrime
+	}
+	dp = new(nat).mod(d, modulusFromNat(pMinus1))
+	dq = new(nat).mod(d, modulusFromNat(qMinus1))
+
+	qinv, ok := new(nat).modInverse(new(nat).mod(q, pModulus), pModulus)
+	if ok != 1 {
+		return nil, nil, nil, errInvalidPrime
+	}
+	return dp, dq, qinv, nil
+}
+
+// blind picks a random r, returning c * r^e mod N, along with r^-1 mod N
+//
+// Decrypting the blinded ciphertext, and then multiplying by r^-1, gives c^d mod N,
+// but the private exponentiation itself only ever operates on a random looking value.
+func blind(random io.Reader, priv *PrivateKey, nModulus *modulus, c *nat) (cBlinded *nat, rInv *nat, err error) {
+	randutil.MaybeReadByte(random)
+
+	for {
+		var r *nat
+		r, err = randNatBelow(random, nModulus)
+		if err != nil {
+			return
+		}
+		// Rejecting candidates leaks nothing about the r we end up using
+		if r.isZero() == 1 {
+			continue
+		}
+		var ok choice
+		rInv, ok = new(nat).modInverse(r, nModulus)
+		if ok != 1 {
+			r.clear()
+			continue
+		}
+		cBlinded = new(nat).expShort(r, uint(priv.E), nModulus)
+		cBlinded.modMul(c, nModulus)
+		r.clear()
+		return cBlinded, rInv, nil
+	}
+}
+
 // decrypt performs an RSA decryption, resulting in a plaintext integer. If a
 // random source is given, RSA blinding is used.
 func decrypt(random io.Reader, priv *PrivateKey, c *nat) (m *nat, err error) {
@@ -508,44 +753,114 @@ func decrypt(random io.Reader, priv *PrivateKey, c *nat) (m *nat, err error) {
 		return nil, ErrDecryption
 	}
 
-	// Note that because our private decryption exponents are stored as big.Int,
-	// we potentially leak the exact number of bits of these exponents. This isn't
-	// great, but should be fine.
+	var rInv *nat
+	if random != nil {
+		c, rInv, err = blind(random, priv, nModulus, c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Our private decryption exponents are stored as big.Int, so converting them
+	// leaks their exact number of bits. expNat at least makes sure that the
+	// exponentiation itself only depends on the size of the modulus.
 	if priv.Precomputed.Dp == nil {
-		m = new(nat).exp(c, priv.D.Bytes(), nModulus)
+		m = new(nat).expNat(c, natFromBig(priv.D), nModulus)
 	} else {
 		primeMod0 := modulusFromNat(natFromBig(priv.Primes[0]))
 		primeMod1 := modulusFromNat(natFromBig(priv.Primes[1]))
 		cMod := new(nat).mod(c, primeMod0)
-		m = new(nat).exp(cMod, priv.Precomputed.Dp.Bytes(), primeMod0)
+		m = new(nat).expNat(cMod, natFromBig(priv.Precomputed.Dp), primeMod0)
 		cMod.mod(c, primeMod1)
-		m2 := new(nat).exp(cMod, priv.Precomputed.Dq.Bytes(), primeMod1)
+		m2 := new(nat).expNat(cMod, natFromBig(priv.Precomputed.Dq), primeMod1)
 		// This value of cMod isn't used later, it's just convenient scratch space
 		m.modSub(cMod.mod(m2, primeMod0), primeMod0)
-		m.modMul(natFromBig(priv.Precomputed.Qinv), primeMod0)
+		m.modMul(natFromBig(priv.Precomputed.Qinv).expandFor(primeMod0), primeMod0)
 		m.expandFor(nModulus)
-		// This expansion mutates primeMod1, but it never gets used anymore, so this is fine
-		m.modMul(primeMod1.nat.expandFor(nModulus), nModulus)
+		m.modMul(primeMod1.nat.clone().expandFor(nModulus), nModulus)
 		m.modAdd(m2.expandFor(nModulus), nModulus)
 
 		mMod := new(nat)
 		for i, values := range priv.Precomputed.CRTValues {
 			prime := modulusFromNat(natFromBig(priv.Primes[2+i]))
 			cMod.mod(c, prime)
-			m2.exp(cMod, values.Exp.Bytes(), prime)
+			m2.expNat(cMod, natFromBig(values.Exp), prime)
 			mMod.mod(m, prime)
-			m2.modSub(m, prime)
-			m2.modMul(natFromBig(values.Coeff), prime)
+			m2.modSub(mMod, prime)
+			m2.modMul(natFromBig(values.Coeff).expandFor(prime), prime)
 			rNat := natFromBig(values.R).expandFor(nModulus)
 			m2.expandFor(nModulus)
 			m2.modMul(rNat, nModulus)
 			m.modAdd(m2, nModulus)
 		}
+		cMod.clear()
+		m2.clear()
+		mMod.clear()
 	}
 
+	if rInv != nil {
+		m.modMul(rInv, nModulus)
+		rInv.clear()
+	}
 	return
 }
 
+// PrimitiveOption configures the behavior of DecryptPrimitive.
+type PrimitiveOption func(*primitiveOptions)
+
+type primitiveOptions struct {
+	random io.Reader
+}
+
+// Blind makes DecryptPrimitive use RSA blinding, with randomness read from random.
+//
+// The exponentiation is then done on c * r^e mod N, for a random r, which
+// gets removed from the result afterwards. The result is the same as without blinding.
+func Blind(random io.Reader) PrimitiveOption {
+	return func(opts *primitiveOptions) {
+		opts.random = random
+	}
+}
+
+// DecryptPrimitive computes the raw RSA operation c^d mod N, without any padding.
+//
+// When the key has been precomputed, this uses the Chinese Remainder Theorem
+// to speed up the operation.
+//
+// Both c and out are big endian, and out must be exactly priv.Size() bytes long.
+// An error is returned if c is not strictly smaller than the modulus.
+//
+// By default, no blinding is done. The Blind option enables it.
+//
+// WARNING: raw RSA is not secure on its own. This is only intended to build
+// padding schemes on top of.
+func (priv *PrivateKey) DecryptPrimitive(out, c []byte, opts ...PrimitiveOption) error {
+	var options primitiveOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := checkPub(&priv.PublicKey); err != nil {
+		return err
+	}
+	k := priv.Size()
+	if len(out) != k {
+		return errors.New("crypto/rsa: output buffer has the wrong size")
+	}
+	if len(c) > k {
+		return ErrDecryption
+	}
+	x, err := natFromBytesChecked(c, modulusFromNat(natFromBig(priv.N)))
+	if err != nil {
+		return ErrDecryption
+	}
+	m, err := decrypt(options.random, priv, x)
+	if err != nil {
+		return err
+	}
+	m.fillBytes(out)
+	return nil
+}
+
 func decryptAndCheck(random io.Reader, priv *PrivateKey, c *nat) (m *nat, err error) {
 	m, err = decrypt(random, priv, c)
 	if err != nil {
@@ -583,7 +898,10 @@ func DecryptOAEP(hash hash.Hash, random io.Reader, priv *PrivateKey, ciphertext
 		return nil, ErrDecryption
 	}
 
-	c := natFromBytes(ciphertext)
+	c, err := natFromBytesChecked(ciphertext, modulusFromNat(natFromBig(priv.N)))
+	if err != nil {
+		return nil, ErrDecryption
+	}
 
 	m, err := decrypt(random, priv, c)
 	if err != nil {
@@ -595,43 +913,57 @@ func DecryptOAEP(hash hash.Hash, random io.Reader, priv *PrivateKey, ciphertext
 	hash.Reset()
 
 	em := m.fillBytes(make([]byte, k))
+	valid, index := oaepUnpad(em, hash, lHash)
+	if valid != 1 {
+		return nil, ErrDecryption
+	}
+	return em[index:], nil
+}
 
-	firstByteIsZero := subtle.ConstantTimeByteEq(em[0], 0)
-
-	seed := em[1 : hash.Size()+1]
-	db := em[hash.Size()+1:]
+// oaepUnpad unmasks em in place, and checks that it's a valid OAEP encoding for a label hashing to lHash
+//
+// This returns 1 in valid if the encoding is correct, along with the index of the
+// message in em, and 0 and 0 otherwise. Only the length of em is leaked.
+//
+// We have to validate the plaintext in constant time in order to avoid
+// attacks like: J. Manger. A Chosen Ciphertext Attack on RSA Optimal
+// Asymmetric Encryption Padding (OAEP) as Standardized in PKCS #1
+// v2.0. In J. Kilian, editor, Advances in Cryptology. That attack
+// distinguishes whether the first byte is zero, so none of the checks
+// return early: they all get folded into valid, before anything depends on it.
+func oaepUnpad(em []byte, hash hash.Hash, lHash []byte) (valid choice, index int) {
+	hLen := hash.Size()
+	firstByteIsZero := ctEq(uint(em[0]), 0)
+
+	seed := em[1 : hLen+1]
+	db := em[hLen+1:]
 
 	mgf1XOR(seed, hash, db)
 	mgf1XOR(db, hash, seed)
 
-	lHash2 := db[0:hash.Size()]
-
-	// We have to validate the plaintext in constant time in order to avoid
-	// attacks like: J. Manger. A Chosen Ciphertext Attack on RSA Optimal
-	// Asymmetric Encryption Padding (OAEP) as Standardized in PKCS #1
-	// v2.0. In J. Kilian, editor, Advances in Cryptology.
-	lHash2Good := subtle.ConstantTimeCompare(lHash, lHash2)
+	lHash2Good := ctBytesEq(lHash, db[0:hLen])
 
 	// The remainder of the plaintext must be zero or more 0x00, followed
 	// by 0x01, followed by the message.
 	//   lookingForIndex: 1 iff we are still looking for the 0x01
-	//   index: the offset of the first 0x01 byte
+	//   oneIndex: the offset of the first 0x01 byte
 	//   invalid: 1 iff we saw a non-zero byte before the 0x01.
-	var lookingForIndex, index, invalid int
-	lookingForIndex = 1
-	rest := db[hash.Size():]
+	lookingForIndex := choice(1)
+	var invalid choice
+	var oneIndex uint
+	rest := db[hLen:]
 
 	for i := 0; i < len(rest); i++ {
-		equals0 := subtle.ConstantTimeByteEq(rest[i], 0)
-		equals1 := subtle.ConstantTimeByteEq(rest[i], 1)
-		index = subtle.ConstantTimeSelect(lookingForIndex&equals1, i, index)
-		lookingForIndex = subtle.ConstantTimeSelect(equals1, 0, lookingForIndex)
-		invalid = subtle.ConstantTimeSelect(lookingForIndex&^equals0, 1, invalid)
-	}
-
-	if firstByteIsZero&lHash2Good&^invalid&^lookingForIndex != 1 {
-		return nil, ErrDecryption
-	}
-
-	return rest[index+1:], nil
+		equals0 := ctEq(uint(rest[i]), 0)
+		equals1 := ctEq(uint(rest[i]), 1)
+		oneIndex = ctIfElse(lookingForIndex&equals1, uint(i), oneIndex)
+		lookingForIndex &= 1 ^ equals1
+		invalid |= lookingForIndex & (1 ^ equals0)
+	}
+
+	// All of the checks are combined, so that we don't leak which one failed
+	valid = firstByteIsZero & lHash2Good & (1 ^ invalid) & (1 ^ lookingForIndex)
+	// rest starts 1 + 2 * hLen bytes into em, and the message right after the 0x01
+	index = int(ctIfElse(valid, uint(1+2*hLen)+oneIndex+1, 0))
+	return valid, index
 }
@@ -8,9 +8,12 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
+	"errors"
 	"math/big"
+	mathrand "math/rand"
 	"testing"
 )
 
@@ -29,6 +32,62 @@ func TestKeyGeneration(t *testing.T) {
 	testKeyBasics(t, priv)
 }
 
+func TestKeyGenerationSignVerify(t *testing.T) {
+	size := 1024
+	if testing.Short() {
+		size = 512
+	}
+	priv, err := GenerateKey(rand.Reader, size)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	if bits := priv.N.BitLen(); bits != size {
+		t.Errorf("key has the wrong size (%d vs %d)", bits, size)
+	}
+	hashed := sha256.Sum256([]byte("testing"))
+	sig, err := SignPKCS1v15(rand.Reader, priv, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %s", err)
+	}
+	if err := VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hashed[:], sig); err != nil {
+		t.Errorf("failed to verify: %s", err)
+	}
+	sig[0] ^= 0x80
+	if err := VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hashed[:], sig); err == nil {
+		t.Errorf("verified a corrupted signature")
+	}
+}
+
+func TestGenerateKeyWithExponent(t *testing.T) {
+	size := 1024
+	if testing.Short() {
+		size = 512
+	}
+	for _, e := range []int{3, 65537} {
+		priv, err := GenerateKeyWithExponent(rand.Reader, size, e)
+		if err != nil {
+			t.Fatalf("e = %d: %s", e, err)
+		}
+		if priv.E != e || priv.N.BitLen() != size {
+			t.Errorf("e = %d: generated a key with e = %d, and %d bits", e, priv.E, priv.N.BitLen())
+		}
+		testKeyBasics(t, priv)
+		hashed := sha256.Sum256([]byte("testing"))
+		sig, err := SignPKCS1v15(rand.Reader, priv, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatalf("e = %d: failed to sign: %s", e, err)
+		}
+		if err := VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hashed[:], sig); err != nil {
+			t.Errorf("e = %d: failed to verify: %s", e, err)
+		}
+	}
+	for _, e := range []int{-3, 0, 1, 2, 4, 65536} {
+		if _, err := GenerateKeyWithExponent(rand.Reader, size, e); err == nil {
+			t.Errorf("e = %d: expected an error", e)
+		}
+	}
+}
+
 func Test3PrimeKeyGeneration(t *testing.T) {
 	size := 768
 	if testing.Short() {
@@ -160,6 +219,318 @@ func testKeyBasics(t *testing.T, priv *PrivateKey) {
 	}
 }
 
+func TestValidate(t *testing.T) {
+	priv, err := GenerateMultiPrimeKey(rand.Reader, 3, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.Validate(); err != nil {
+		t.Fatalf("valid key: %s", err)
+	}
+	// Each corruption works on a fresh copy of the key
+	corrupt := func(modify func(k *PrivateKey)) *PrivateKey {
+		k := &PrivateKey{
+			PublicKey: PublicKey{N: new(big.Int).Set(priv.N), E: priv.E},
+			D:         new(big.Int).Set(priv.D),
+			Precomputed: PrecomputedValues{
+				Dp:   new(big.Int).Set(priv.Precomputed.Dp),
+				Dq:   new(big.Int).Set(priv.Precomputed.Dq),
+				Qinv: new(big.Int).Set(priv.Precomputed.Qinv),
+			},
+		}
+		for _, p := range priv.Primes {
+			k.Primes = append(k.Primes, new(big.Int).Set(p))
+		}
+		for _, v := range priv.Precomputed.CRTValues {
+			k.Precomputed.CRTValues = append(k.Precomputed.CRTValues, CRTValue{
+				Exp:   new(big.Int).Set(v.Exp),
+				Coeff: new(big.Int).Set(v.Coeff),
+				R:     new(big.Int).Set(v.R),
+			})
+		}
+		modify(k)
+		return k
+	}
+	if err := corrupt(func(*PrivateKey) {}).Validate(); err != nil {
+		t.Fatalf("copied key: %s", err)
+	}
+	examples := map[string]*PrivateKey{
+		"wrong modulus":   corrupt(func(k *PrivateKey) { k.N.Add(k.N, big.NewInt(2)) }),
+		"composite prime": corrupt(func(k *PrivateKey) { k.Primes = []*big.Int{k.N} }),
+		"missing prime":   corrupt(func(k *PrivateKey) { k.Primes = k.Primes[:2] }),
+		"wrong exponent":  corrupt(func(k *PrivateKey) { k.D.Add(k.D, bigOne) }),
+		"wrong dp":        corrupt(func(k *PrivateKey) { k.Precomputed.Dp.Add(k.Precomputed.Dp, bigOne) }),
+		"wrong dq":        corrupt(func(k *PrivateKey) { k.Precomputed.Dq.Add(k.Precomputed.Dq, bigOne) }),
+		"missing dq":      corrupt(func(k *PrivateKey) { k.Precomputed.Dq = nil }),
+		"wrong qinv":      corrupt(func(k *PrivateKey) { k.Precomputed.Qinv.Add(k.Precomputed.Qinv, bigOne) }),
+		"wrong coeff":     corrupt(func(k *PrivateKey) { k.Precomputed.CRTValues[0].Coeff.Add(k.Precomputed.CRTValues[0].Coeff, bigOne) }),
+		"wrong r":         corrupt(func(k *PrivateKey) { k.Precomputed.CRTValues[0].R.Add(k.Precomputed.CRTValues[0].R, bigOne) }),
+		"missing values":  corrupt(func(k *PrivateKey) { k.Precomputed.CRTValues = nil }),
+	}
+	for name, k := range examples {
+		if err := k.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNewPublicKey(t *testing.T) {
+	pub, err := NewPublicKey(rsaPrivateKey.N.Bytes(), 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&rsaPrivateKey.PublicKey) {
+		t.Errorf("got %v, want %v", pub, &rsaPrivateKey.PublicKey)
+	}
+	if _, err := NewPublicKey(rsaPrivateKey.N.Bytes(), 1); err == nil {
+		t.Errorf("accepted a public exponent of 1")
+	}
+	if _, err := NewPublicKey(rsaPrivateKey.N.Bytes(), 1<<31); err == nil {
+		t.Errorf("accepted an overly large public exponent")
+	}
+	even := new(big.Int).Add(rsaPrivateKey.N, bigOne)
+	if _, err := NewPublicKey(even.Bytes(), 65537); err == nil {
+		t.Errorf("accepted an even modulus")
+	}
+	for _, zero := range [][]byte{nil, {0}, make([]byte, 64)} {
+		if _, err := NewPublicKey(zero, 65537); err == nil {
+			t.Errorf("accepted a zero modulus %x", zero)
+		}
+	}
+}
+
+func TestPrivateKeyPublic(t *testing.T) {
+	hashed := sha256.Sum256([]byte("testing"))
+	sig, err := test2048Key.SignPKCS1v15(rand.Reader, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, ok := test2048Key.Public().(*PublicKey)
+	if !ok {
+		t.Fatalf("Public returned %T", test2048Key.Public())
+	}
+	if err := pub.VerifyPKCS1v15(crypto.SHA256, hashed[:], sig); err != nil {
+		t.Error(err)
+	}
+
+	// A key without its modulus gets it back from its primes
+	priv := &PrivateKey{PublicKey: PublicKey{E: test2048Key.E}, D: test2048Key.D, Primes: test2048Key.Primes}
+	pub = priv.Public().(*PublicKey)
+	if !pub.Equal(&test2048Key.PublicKey) {
+		t.Errorf("reconstructed public key doesn't match")
+	}
+	if err := pub.VerifyPKCS1v15(crypto.SHA256, hashed[:], sig); err != nil {
+		t.Error(err)
+	}
+	// Public is only a getter, leaving the key untouched
+	if priv.N != nil {
+		t.Errorf("Public modified the key")
+	}
+	priv.Precompute()
+	if priv.N == nil || priv.N.Cmp(test2048Key.N) != 0 || priv.Public() != crypto.PublicKey(&priv.PublicKey) {
+		t.Errorf("Precompute didn't store the modulus")
+	}
+}
+
+func TestPublicKeyEqual(t *testing.T) {
+	pub := &rsaPrivateKey.PublicKey
+	if !pub.Equal(pub) {
+		t.Errorf("public key is not equal to itself")
+	}
+	copied := &PublicKey{N: new(big.Int).Set(pub.N), E: pub.E}
+	if !pub.Equal(copied) || !pub.Equal(rsaPrivateKey.Public()) {
+		t.Errorf("equivalent public keys are not Equal")
+	}
+	others := map[string]crypto.PublicKey{
+		"other exponent":  &PublicKey{N: pub.N, E: 3},
+		"other modulus":   &PublicKey{N: new(big.Int).Add(pub.N, big.NewInt(2)), E: pub.E},
+		"shorter modulus": &PublicKey{N: big.NewInt(13), E: pub.E},
+		"standard key":    &rsa.PublicKey{N: pub.N, E: pub.E},
+		"nil key":         (*PublicKey)(nil),
+		"nil":             nil,
+	}
+	for name, other := range others {
+		if pub.Equal(other) {
+			t.Errorf("%s: different public keys are Equal", name)
+		}
+	}
+}
+
+func TestEvenModulusRejected(t *testing.T) {
+	pub := &PublicKey{N: new(big.Int).Add(rsaPrivateKey.N, bigOne), E: 65537}
+	hashed := sha256.Sum256([]byte("testing"))
+	sig := make([]byte, pub.Size())
+	if err := VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sig); err == nil {
+		t.Errorf("VerifyPKCS1v15 accepted an even modulus")
+	}
+	if err := VerifyPSS(pub, crypto.SHA256, hashed[:], sig, nil); err == nil {
+		t.Errorf("VerifyPSS accepted an even modulus")
+	}
+	if _, err := EncryptOAEP(sha256.New(), rand.Reader, pub, []byte("hello"), nil); err == nil {
+		t.Errorf("EncryptOAEP accepted an even modulus")
+	}
+}
+
+func TestZeroModulusRejected(t *testing.T) {
+	pub := &PublicKey{N: new(big.Int), E: 65537}
+	if err := VerifyPKCS1v15(pub, crypto.Hash(0), []byte("hello"), nil); err == nil {
+		t.Errorf("VerifyPKCS1v15 accepted a zero modulus")
+	}
+	if err := VerifyPSS(pub, crypto.SHA256, make([]byte, 32), nil, nil); err == nil {
+		t.Errorf("VerifyPSS accepted a zero modulus")
+	}
+	if err := pub.EncryptPrimitive(nil, nil); err == nil {
+		t.Errorf("EncryptPrimitive accepted a zero modulus")
+	}
+}
+
+func TestEncryptPrimitive(t *testing.T) {
+	pub := &rsaPrivateKey.PublicKey
+	k := pub.Size()
+	std := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: pub.N, E: pub.E},
+		D:         rsaPrivateKey.D,
+		Primes:    rsaPrivateKey.Primes,
+	}
+	std.Precompute()
+
+	msg := []byte("hello, world")
+	// EM = 0x00 || 0x02 || PS || 0x00 || M
+	em := make([]byte, k)
+	em[1] = 2
+	for i := 2; i < k-len(msg)-1; i++ {
+		em[i] = 0x42
+	}
+	copy(em[k-len(msg):], msg)
+
+	c := make([]byte, k)
+	if err := pub.EncryptPrimitive(c, em); err != nil {
+		t.Fatal(err)
+	}
+	expected := new(big.Int).Exp(new(big.Int).SetBytes(em), big.NewInt(int64(pub.E)), pub.N)
+	if new(big.Int).SetBytes(c).Cmp(expected) != 0 {
+		t.Errorf("got %x, want %x", c, expected)
+	}
+	decrypted, err := rsa.DecryptPKCS1v15(nil, std, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, msg) {
+		t.Errorf("got %q, want %q", decrypted, msg)
+	}
+
+	if err := pub.EncryptPrimitive(c, pub.N.Bytes()); err == nil {
+		t.Errorf("accepted a message equal to the modulus")
+	}
+	if err := pub.EncryptPrimitive(c, make([]byte, k+1)); err == nil {
+		t.Errorf("accepted an overly long message")
+	}
+	if err := pub.EncryptPrimitive(make([]byte, k-1), em); err == nil {
+		t.Errorf("accepted a short output buffer")
+	}
+}
+
+func TestDecryptPrimitiveCRT(t *testing.T) {
+	size := 1024
+	if testing.Short() {
+		size = 256
+	}
+	for i := 0; i < 6; i++ {
+		// Multi-prime keys go through the extra CRT values
+		nprimes := 2 + i%3
+		priv, err := GenerateMultiPrimeKey(rand.Reader, nprimes, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(priv.Precomputed.CRTValues) != nprimes-2 {
+			t.Fatalf("%d primes: %d CRT values", nprimes, len(priv.Precomputed.CRTValues))
+		}
+		// Without the precomputed values, decryption falls back to c^d mod N
+		slow := &PrivateKey{PublicKey: priv.PublicKey, D: priv.D, Primes: priv.Primes}
+
+		c, err := rand.Int(rand.Reader, priv.N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cBytes := c.FillBytes(make([]byte, priv.Size()))
+		fast := make([]byte, priv.Size())
+		if err := priv.DecryptPrimitive(fast, cBytes); err != nil {
+			t.Fatal(err)
+		}
+		expected := make([]byte, priv.Size())
+		if err := slow.DecryptPrimitive(expected, cBytes); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(fast, expected) {
+			t.Errorf("%d primes: CRT result %x != %x", nprimes, fast, expected)
+		}
+		bigExpected := new(big.Int).Exp(c, priv.D, priv.N)
+		if new(big.Int).SetBytes(fast).Cmp(bigExpected) != 0 {
+			t.Errorf("%d primes: got %x, want %x", nprimes, fast, bigExpected)
+		}
+	}
+}
+
+func TestDecryptMultiPrimeRecombination(t *testing.T) {
+	// For the third prime onwards, the partial result modulo N needs to be
+	// reduced modulo that prime before being subtracted from its exponentiation.
+	priv, err := GenerateMultiPrimeKey(rand.Reader, 3, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		msg, err := rand.Int(rand.Reader, priv.N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := new(big.Int).Exp(msg, big.NewInt(int64(priv.E)), priv.N)
+		out := make([]byte, priv.Size())
+		if err := priv.DecryptPrimitive(out, c.FillBytes(make([]byte, priv.Size()))); err != nil {
+			t.Fatal(err)
+		}
+		if actual := new(big.Int).SetBytes(out); actual.Cmp(msg) != 0 {
+			t.Errorf("%x != %x", actual, msg)
+		}
+	}
+}
+
+func TestDecryptPrimitiveBlinded(t *testing.T) {
+	for _, priv := range []*PrivateKey{rsaPrivateKey, test2048Key} {
+		slow := &PrivateKey{PublicKey: priv.PublicKey, D: priv.D, Primes: priv.Primes}
+		for _, c := range []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(42), new(big.Int).Sub(priv.N, big.NewInt(1))} {
+			cBytes := c.FillBytes(make([]byte, priv.Size()))
+			for _, key := range []*PrivateKey{priv, slow} {
+				expected := make([]byte, priv.Size())
+				if err := key.DecryptPrimitive(expected, cBytes); err != nil {
+					t.Fatal(err)
+				}
+				actual := make([]byte, priv.Size())
+				if err := key.DecryptPrimitive(actual, cBytes, Blind(rand.Reader)); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(actual, expected) {
+					t.Errorf("blinded result %x != %x", actual, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestDecryptPrimitiveBlindedShortReader(t *testing.T) {
+	out := make([]byte, rsaPrivateKey.Size())
+	c := make([]byte, rsaPrivateKey.Size())
+	if err := rsaPrivateKey.DecryptPrimitive(out, c, Blind(bytes.NewReader(nil))); err == nil {
+		t.Errorf("blinding succeeded without any randomness")
+	}
+}
+
+func TestDecryptPrimitiveOutOfRange(t *testing.T) {
+	out := make([]byte, rsaPrivateKey.Size())
+	if err := rsaPrivateKey.DecryptPrimitive(out, rsaPrivateKey.N.Bytes()); err == nil {
+		t.Errorf("accepted a ciphertext equal to the modulus")
+	}
+}
+
 func fromBase10(base10 string) *big.Int {
 	i, ok := new(big.Int).SetString(base10, 10)
 	if !ok {
@@ -197,6 +568,19 @@ func BenchmarkRSA2048Decrypt(b *testing.B) {
 	}
 }
 
+func BenchmarkRSA2048DecryptNoCRT(b *testing.B) {
+	b.StopTimer()
+
+	c := fromBase10("8472002792838218989464636159316973636630013835787202418124758118372358261975764365740026024610403138425986214991379012696600761514742817632790916315594342398720903716529235119816755589383377471752116975374952783629225022962092351886861518911824745188989071172097120352727368980275252089141512321893536744324822590480751098257559766328893767334861211872318961900897793874075248286439689249972315699410830094164386544311554704755110361048571142336148077772023880664786019636334369759624917224888206329520528064315309519262325023881707530002540634660750469137117568199824615333883758410040459705787022909848740188613313")
+	priv := &PrivateKey{PublicKey: test2048Key.PublicKey, D: test2048Key.D, Primes: test2048Key.Primes}
+
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		decrypt(nil, priv, natFromBig(c))
+	}
+}
+
 func BenchmarkRSA2048Sign(b *testing.B) {
 	b.StopTimer()
 	hashed := sha256.Sum256([]byte("testing"))
@@ -300,6 +684,183 @@ func TestDecryptOAEP(t *testing.T) {
 	}
 }
 
+func TestOAEPInterop(t *testing.T) {
+	std := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: test2048Key.N, E: test2048Key.E},
+		D:         test2048Key.D,
+		Primes:    test2048Key.Primes,
+	}
+	std.Precompute()
+	msg := []byte("hello, OAEP")
+	label := []byte("label")
+
+	c, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &std.PublicKey, msg, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecryptOAEP(sha256.New(), rand.Reader, test2048Key, c, label)
+	if err != nil {
+		t.Errorf("failed to decrypt crypto/rsa ciphertext: %s", err)
+	} else if !bytes.Equal(out, msg) {
+		t.Errorf("got %x, want %x", out, msg)
+	}
+
+	c, err = EncryptOAEP(sha256.New(), rand.Reader, &test2048Key.PublicKey, msg, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, std, c, label)
+	if err != nil {
+		t.Errorf("crypto/rsa failed to decrypt ciphertext: %s", err)
+	} else if !bytes.Equal(out, msg) {
+		t.Errorf("got %x, want %x", out, msg)
+	}
+}
+
+func TestDecrypter(t *testing.T) {
+	var decrypter crypto.Decrypter = rsaPrivateKey
+	pub := &rsaPrivateKey.PublicKey
+	msg := []byte("sixteen byte key")
+
+	oaep, err := EncryptOAEP(sha1.New(), rand.Reader, pub, msg, []byte("label"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := decrypter.Decrypt(rand.Reader, oaep, &OAEPOptions{Hash: crypto.SHA1, Label: []byte("label")})
+	if err != nil || !bytes.Equal(plaintext, msg) {
+		t.Errorf("OAEP: got %x, %v", plaintext, err)
+	}
+	if _, err := decrypter.Decrypt(rand.Reader, oaep, &OAEPOptions{Hash: crypto.SHA1}); err == nil {
+		t.Errorf("OAEP: accepted the wrong label")
+	}
+
+	pkcs1, err := EncryptPKCS1v15(rand.Reader, pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []crypto.DecrypterOpts{nil, &PKCS1v15DecryptOptions{}, &PKCS1v15DecryptOptions{SessionKeyLen: len(msg)}} {
+		plaintext, err := decrypter.Decrypt(rand.Reader, pkcs1, opts)
+		if err != nil || !bytes.Equal(plaintext, msg) {
+			t.Errorf("PKCS #1 v1.5 %+v: got %x, %v", opts, plaintext, err)
+		}
+	}
+	// Invalid padding produces a random session key, rather than an error
+	invalid := make([]byte, pub.Size())
+	invalid[1] = 3
+	if err := pub.EncryptPrimitive(invalid, invalid); err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err = decrypter.Decrypt(rand.Reader, invalid, &PKCS1v15DecryptOptions{SessionKeyLen: len(msg)})
+	if err != nil || len(plaintext) != len(msg) || bytes.Equal(plaintext, msg) {
+		t.Errorf("PKCS #1 v1.5 session key: got %x, %v", plaintext, err)
+	}
+	if _, err := decrypter.Decrypt(rand.Reader, invalid, &PKCS1v15DecryptOptions{}); err == nil {
+		t.Errorf("PKCS #1 v1.5: accepted invalid padding")
+	}
+
+	if _, err := decrypter.Decrypt(rand.Reader, pkcs1, &PSSOptions{}); err == nil {
+		t.Errorf("accepted invalid options")
+	}
+}
+
+func TestDecryptOAEPMalformed(t *testing.T) {
+	hash := sha256.New()
+	hLen := hash.Size()
+	k := test2048Key.Size()
+	msg := []byte("hello, OAEP")
+	lHash := sha256.Sum256(nil)
+
+	// encode builds an OAEP encoding by hand, letting us break it afterwards
+	encode := func(corrupt func(em, db []byte)) []byte {
+		em := make([]byte, k)
+		seed := em[1 : 1+hLen]
+		db := em[1+hLen:]
+		copy(db, lHash[:])
+		db[len(db)-len(msg)-1] = 1
+		copy(db[len(db)-len(msg):], msg)
+		corrupt(em, db)
+		rand.Read(seed)
+		mgf1XOR(db, hash, seed)
+		mgf1XOR(seed, hash, db)
+		c := make([]byte, k)
+		if err := test2048Key.PublicKey.EncryptPrimitive(c, em); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	c := encode(func(em, db []byte) {})
+	if out, err := DecryptOAEP(hash, nil, test2048Key, c, nil); err != nil || !bytes.Equal(out, msg) {
+		t.Errorf("failed to decrypt valid encoding: %x, %v", out, err)
+	}
+
+	corruptions := map[string]func(em, db []byte){
+		"first byte":    func(em, db []byte) { em[0] = 1 },
+		"label hash":    func(em, db []byte) { db[0] ^= 1 },
+		"separator":     func(em, db []byte) { db[len(db)-len(msg)-1] = 2 },
+		"no separator":  func(em, db []byte) { db[len(db)-len(msg)-1] = 0 },
+		"nonzero in PS": func(em, db []byte) { db[hLen] = 0xFF },
+	}
+	for name, corrupt := range corruptions {
+		c := encode(corrupt)
+		if _, err := DecryptOAEP(hash, nil, test2048Key, c, nil); err != ErrDecryption {
+			t.Errorf("%s: expected ErrDecryption, got %v", name, err)
+		}
+	}
+}
+
+func TestOAEPUnpadEveryStructuralByte(t *testing.T) {
+	hash := sha256.New()
+	hLen := hash.Size()
+	k := 256
+	msg := []byte("hello, OAEP")
+	lHash := sha256.Sum256(nil)
+	separator := k - len(msg) - 1
+
+	// encode builds an unmasked encoding, with the separator at em[separator]
+	encode := func() []byte {
+		em := make([]byte, k)
+		copy(em[1+hLen:], lHash[:])
+		em[separator] = 1
+		copy(em[separator+1:], msg)
+		return em
+	}
+	// mask applies the OAEP masks in place, and returns em
+	mask := func(em []byte) []byte {
+		seed, db := em[1:1+hLen], em[1+hLen:]
+		for i := range seed {
+			seed[i] = byte(i)
+		}
+		mgf1XOR(db, hash, seed)
+		mgf1XOR(seed, hash, db)
+		return em
+	}
+
+	valid, index := oaepUnpad(mask(encode()), hash, lHash[:])
+	if valid != 1 || index != separator+1 {
+		t.Fatalf("valid encoding: valid = %d, index = %d", valid, index)
+	}
+	// Every byte with a fixed value, from the leading zero, through the label hash,
+	// and the padding, up to the separator, makes decoding fail in the same way
+	for i := 0; i <= separator; i++ {
+		if i >= 1 && i < 1+hLen {
+			// These are seed bytes, which can have any value
+			continue
+		}
+		for _, flip := range []byte{0x01, 0x02, 0x80, 0xFF} {
+			em := encode()
+			em[i] ^= flip
+			if i >= 1+2*hLen && em[i] == 1 {
+				// This just moves the separator earlier, which is still a valid encoding
+				continue
+			}
+			if valid, index := oaepUnpad(mask(em), hash, lHash[:]); valid != 0 || index != 0 {
+				t.Errorf("byte %d ^ %x: valid = %d, index = %d", i, flip, valid, index)
+			}
+		}
+	}
+}
+
 // testEncryptOAEPData contains a subset of the vectors from RSA's "Test vectors for RSA-OAEP".
 var testEncryptOAEPData = []testEncryptOAEPStruct{
 	// Key 1
@@ -446,3 +1007,106 @@ var testEncryptOAEPData = []testEncryptOAEPStruct{
 		},
 	},
 }
+
+func TestErrorsIs(t *testing.T) {
+	pub := &rsaPrivateKey.PublicKey
+	nBytes := pub.N.Bytes()
+	even := append([]byte{}, nBytes...)
+	even[len(even)-1] &^= 1
+	_, errEven := NewPublicKey(even, 65537)
+	_, errSmall := NewPublicKey(nBytes, 1)
+	badD := *rsaPrivateKey
+	badD.D = new(big.Int).Add(rsaPrivateKey.D, bigOne)
+
+	hashed := sha1.Sum([]byte("message"))
+	sig, err := SignPKCS1v15(nil, rsaPrivateKey, crypto.SHA1, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pssSig, err := SignPSS(rand.Reader, rsaPrivateKey, crypto.SHA1, hashed[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[len(sig)-1] ^= 1
+	pssSig[len(pssSig)-1] ^= 1
+	garbage := make([]byte, pub.Size())
+	garbage[len(garbage)-1] = 2
+	_, errOAEP := DecryptOAEP(sha1.New(), nil, rsaPrivateKey, garbage, nil)
+	_, errPKCS1v15 := DecryptPKCS1v15(nil, rsaPrivateKey, garbage)
+	_, errTooLarge := DecryptPKCS1v15(nil, rsaPrivateKey, append(garbage, 0))
+	_, errTooLong := EncryptOAEP(sha1.New(), rand.Reader, pub, make([]byte, pub.Size()), nil)
+
+	examples := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"even modulus", errEven, ErrInvalidKey},
+		{"small exponent", errSmall, ErrInvalidKey},
+		{"wrong private exponent", badD.Validate(), ErrInvalidKey},
+		{"OAEP padding", errOAEP, ErrDecryption},
+		{"PKCS #1 v1.5 padding", errPKCS1v15, ErrDecryption},
+		{"large ciphertext", errTooLarge, ErrDecryption},
+		{"long message", errTooLong, ErrMessageTooLong},
+		{"PKCS #1 v1.5 signature", VerifyPKCS1v15(pub, crypto.SHA1, hashed[:], sig), ErrVerification},
+		{"PSS signature", VerifyPSS(pub, crypto.SHA1, hashed[:], pssSig, nil), ErrVerification},
+	}
+	sentinels := []error{ErrInvalidKey, ErrDecryption, ErrVerification, ErrMessageTooLong}
+	for _, example := range examples {
+		for _, sentinel := range sentinels {
+			if errors.Is(example.err, sentinel) != (sentinel == example.expected) {
+				t.Errorf("%s: errors.Is(%v, %v) != %v", example.name, example.err, sentinel, sentinel == example.expected)
+			}
+		}
+	}
+}
+
+func TestGenerateKeyDeterministic(t *testing.T) {
+	// The expected modulus was produced by this very function, so that any change
+	// to how the random bytes get consumed shows up here.
+	expected, _ := new(big.Int).SetString("e42ff62b14c736c008e49eda0f9f271a4883277f97b42118cbedc546e84b3bd4"+
+		"806b2a4b5afe3995fe34b047a64d4a0c3aa41a3d464dd8b9bf3044db34727383", 16)
+	for i := 0; i < 2; i++ {
+		priv, err := generateMultiPrimeKey(mathrand.New(mathrand.NewSource(1)), 2, 512, 65537)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.N.Cmp(expected) != 0 {
+			t.Errorf("%d: N = %x, expected %x", i, priv.N, expected)
+		}
+		testKeyBasics(t, priv)
+	}
+}
+
+func TestComputeCRTParams(t *testing.T) {
+	for _, size := range []int{512, 1024, 2048} {
+		key, err := rsa.GenerateKey(rand.Reader, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.Precompute()
+		dp, dq, qinv, err := computeCRTParams(natFromBig(key.D), natFromBig(key.Primes[0]), natFromBig(key.Primes[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dp.toBig().Cmp(key.Precomputed.Dp) != 0 || dq.toBig().Cmp(key.Precomputed.Dq) != 0 || qinv.toBig().Cmp(key.Precomputed.Qinv) != 0 {
+			t.Errorf("%d bits: CRT values don't match crypto/rsa", size)
+		}
+	}
+
+	d, p := natFromBig(rsaPrivateKey.D), natFromBig(rsaPrivateKey.Primes[0])
+	examples := map[string]*nat{
+		"same primes": p,
+		"even":        natFromBig(big.NewInt(1 << 20)),
+		"one":         natFromBig(bigOne),
+		"zero":        new(nat),
+	}
+	for name, q := range examples {
+		if _, _, _, err := computeCRTParams(d, p, q); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if _, _, _, err := computeCRTParams(d, q, p); err == nil {
+			t.Errorf("%s, swapped: expected an error", name)
+		}
+	}
+}
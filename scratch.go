@@ -0,0 +1,67 @@
+package ctrsa
+
+import "sync"
+
+// expScratch holds the buffers used by expWindow, for a given modulus size and window size
+//
+// Exponentiation is the hot path of every private key operation, so reusing these
+// buffers across calls takes a lot of pressure off of the garbage collector.
+type expScratch struct {
+	key expScratchKey
+	// table[i] holds x^(i + 1), in montgomery representation
+	table    []*nat
+	selected *montyNat
+	scratch  *montyNat
+}
+
+// expScratchKey identifies which pool some scratch buffers belong to
+type expScratchKey struct {
+	limbs int
+	w     uint
+}
+
+// expScratchPools maps each expScratchKey to a *sync.Pool of *expScratch values
+var expScratchPools sync.Map
+
+// getExpScratch returns zeroed buffers for exponentiation modulo m, with windows of w bits
+//
+// The buffers should be handed back with putExpScratch once they're no longer used.
+func getExpScratch(m *modulus, w uint) *expScratch {
+	key := expScratchKey{limbs: len(m.nat.limbs), w: w}
+	pool, ok := expScratchPools.Load(key)
+	if !ok {
+		pool, _ = expScratchPools.LoadOrStore(key, &sync.Pool{New: func() interface{} {
+			return newExpScratch(key)
+		}})
+	}
+	return pool.(*sync.Pool).Get().(*expScratch)
+}
+
+// newExpScratch allocates fresh buffers, for the pool associated with key
+func newExpScratch(key expScratchKey) *expScratch {
+	s := &expScratch{
+		key:      key,
+		table:    make([]*nat, (1<<key.w)-1),
+		selected: (*montyNat)(new(nat).expand(key.limbs)),
+		scratch:  (*montyNat)(new(nat).expand(key.limbs)),
+	}
+	for i := range s.table {
+		s.table[i] = new(nat).expand(key.limbs)
+	}
+	return s
+}
+
+// putExpScratch zeroes the buffers in s, and returns them to their pool
+//
+// The buffers hold powers of the base, and intermediate results, which may be secret,
+// so they can't be left lying around, even when unused.
+func putExpScratch(s *expScratch) {
+	for _, xi := range s.table {
+		xi.clear()
+	}
+	s.selected.clear()
+	s.scratch.clear()
+	if pool, ok := expScratchPools.Load(s.key); ok {
+		pool.(*sync.Pool).Put(s)
+	}
+}
@@ -0,0 +1,64 @@
+package ctrsa
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPutExpScratchClears(t *testing.T) {
+	m := modulusFromNat(&nat{[]uint{13, 13}})
+	s := getExpScratch(m, 3)
+	if len(s.table) != 7 || len(s.selected.limbs) != 2 || len(s.scratch.limbs) != 2 {
+		t.Fatalf("wrong buffer sizes")
+	}
+	for _, xi := range append(s.table, (*nat)(s.selected), (*nat)(s.scratch)) {
+		xi.limbs[0], xi.limbs[1] = 1, 2
+	}
+	putExpScratch(s)
+	for _, xi := range append(s.table, (*nat)(s.selected), (*nat)(s.scratch)) {
+		if xi.isZero() != 1 {
+			t.Errorf("%+v wasn't cleared", xi)
+		}
+	}
+}
+
+func TestExpWithPooledScratch(t *testing.T) {
+	m := modulusFromNat(&nat{[]uint{13, 13}})
+	x := &nat{[]uint{3, 0}}
+	expected := new(nat).expWindow(x, []byte{5}, 4, m)
+	// Later calls reuse the buffers, and shouldn't see any leftovers
+	for i := 0; i < 10; i++ {
+		if actual := new(nat).expWindow(x, []byte{5}, 4, m); actual.cmpEq(expected) != 1 {
+			t.Errorf("%+v != %+v", actual, expected)
+		}
+		// Aliasing the base with the output should also work
+		y := x.clone()
+		if y.expWindow(y, []byte{5}, 4, m); y.cmpEq(expected) != 1 {
+			t.Errorf("%+v != %+v", y, expected)
+		}
+	}
+}
+
+func TestWindowTableIndependentOfExponent(t *testing.T) {
+	m := modulusFromNat(&nat{[]uint{13, 13}})
+	x := &nat{[]uint{3, 0}}
+	// Tiny and large exponents of the same length use the same table size
+	w := expWindowSize(len(padExponent([]byte{1}, m)) * 8)
+	if other := expWindowSize(len(padExponent([]byte{0xFF, 0xFF, 0xFF}, m)) * 8); other != w {
+		t.Errorf("window sizes %d != %d", other, w)
+	}
+	// Every entry of the table is computed, without looking at the exponent
+	s := getExpScratch(m, w)
+	defer putExpScratch(s)
+	precomputeWindowTable(s.table, x, m)
+	if len(s.table) != (1<<w)-1 {
+		t.Fatalf("table has %d entries, expected %d", len(s.table), (1<<w)-1)
+	}
+	for i, xi := range s.table {
+		expected := new(big.Int).Exp(big.NewInt(3), big.NewInt(int64(i+1)), m.nat.toBig())
+		if actual := new(nat).fromMonty((*montyNat)(xi), m); actual.toBig().Cmp(expected) != 0 {
+			t.Errorf("table[%d]: %+v != %v", i, actual, expected)
+		}
+	}
+
+}
@@ -0,0 +1,71 @@
+package ctrsa
+
+import (
+	"crypto/rsa"
+	"math/big"
+)
+
+// PublicKeyFromStd converts a public key from crypto/rsa into a public key for this package
+//
+// The key goes through the same checks as NewPublicKey.
+func PublicKeyFromStd(k *rsa.PublicKey) (*PublicKey, error) {
+	if k.N == nil {
+		return nil, errPublicModulus
+	}
+	if k.E < 0 {
+		return nil, errPublicExponentSmall
+	}
+	return NewPublicKey(k.N.Bytes(), uint(k.E))
+}
+
+// PrivateKeyFromStd converts a private key from crypto/rsa into a private key for this package
+//
+// The key goes through Validate, and then gets its CRT values precomputed. None
+// of the values of k are shared with the result.
+func PrivateKeyFromStd(k *rsa.PrivateKey) (*PrivateKey, error) {
+	pub, err := PublicKeyFromStd(&k.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if k.D == nil {
+		return nil, errPrivateExponent
+	}
+	priv := &PrivateKey{PublicKey: *pub, D: new(big.Int).Set(k.D)}
+	for _, prime := range k.Primes {
+		if prime == nil {
+			return nil, errInvalidPrime
+		}
+		priv.Primes = append(priv.Primes, new(big.Int).Set(prime))
+	}
+	if len(priv.Primes) < 2 {
+		return nil, errTooFewPrimes
+	}
+	if err := priv.Validate(); err != nil {
+		return nil, err
+	}
+	priv.Precompute()
+	return priv, nil
+}
+
+// ToStd converts pub into a public key from crypto/rsa
+//
+// The result doesn't share any values with pub.
+func (pub *PublicKey) ToStd() *rsa.PublicKey {
+	return &rsa.PublicKey{N: new(big.Int).Set(pub.N), E: pub.E}
+}
+
+// ToStd converts priv into a private key from crypto/rsa
+//
+// The CRT values are computed again, with the Precompute method from crypto/rsa,
+// which also sets up its internal state. The result doesn't share any values with priv.
+func (priv *PrivateKey) ToStd() *rsa.PrivateKey {
+	key := &rsa.PrivateKey{
+		PublicKey: *priv.PublicKey.ToStd(),
+		D:         new(big.Int).Set(priv.D),
+	}
+	for _, prime := range priv.Primes {
+		key.Primes = append(key.Primes, new(big.Int).Set(prime))
+	}
+	key.Precompute()
+	return key
+}
@@ -0,0 +1,120 @@
+package ctrsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestFromStd(t *testing.T) {
+	for _, nprimes := range []int{2, 3} {
+		key, err := rsa.GenerateMultiPrimeKey(rand.Reader, nprimes, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashed := sha256.Sum256([]byte("testing"))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pub, err := PublicKeyFromStd(&key.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pub.VerifyPKCS1v15(crypto.SHA256, hashed[:], sig); err != nil {
+			t.Errorf("%d primes: converted public key rejected signature: %s", nprimes, err)
+		}
+
+		priv, err := PrivateKeyFromStd(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.D == key.D || priv.Primes[0] == key.Primes[0] {
+			t.Errorf("%d primes: converted key shares values with the original", nprimes)
+		}
+		ours, err := priv.SignPKCS1v15(rand.Reader, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], ours); err != nil {
+			t.Errorf("%d primes: crypto/rsa rejected signature: %s", nprimes, err)
+		}
+		testKeyBasics(t, priv)
+	}
+}
+
+func TestFromStdInvalid(t *testing.T) {
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: test2048Key.N, E: test2048Key.E},
+		D:         test2048Key.D,
+		Primes:    test2048Key.Primes,
+	}
+	examples := map[string]func(k *rsa.PrivateKey){
+		"missing modulus": func(k *rsa.PrivateKey) { k.N = nil },
+		"negative e":      func(k *rsa.PrivateKey) { k.E = -3 },
+		"missing d":       func(k *rsa.PrivateKey) { k.D = nil },
+		"wrong d":         func(k *rsa.PrivateKey) { k.D = new(big.Int).Add(k.D, bigOne) },
+		"single prime":    func(k *rsa.PrivateKey) { k.Primes = k.Primes[:1] },
+		"missing prime":   func(k *rsa.PrivateKey) { k.Primes = []*big.Int{k.Primes[0], nil} },
+		"swapped modulus": func(k *rsa.PrivateKey) { k.N = rsaPrivateKey.N },
+	}
+	for name, modify := range examples {
+		k := *key
+		modify(&k)
+		if _, err := PrivateKeyFromStd(&k); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := PrivateKeyFromStd(key); err != nil {
+		t.Errorf("unmodified key: %s", err)
+	}
+}
+
+func TestStdRoundtrip(t *testing.T) {
+	for _, nprimes := range []int{2, 3} {
+		priv, err := GenerateMultiPrimeKey(rand.Reader, nprimes, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := priv.ToStd()
+		if err := key.Validate(); err != nil {
+			t.Errorf("%d primes: crypto/rsa rejected the key: %s", nprimes, err)
+		}
+		if key.N.Cmp(priv.N) != 0 || key.E != priv.E || key.D.Cmp(priv.D) != 0 || len(key.Primes) != nprimes {
+			t.Errorf("%d primes: exported key doesn't match", nprimes)
+		}
+		if key.N == priv.N || key.D == priv.D {
+			t.Errorf("%d primes: exported key shares values with the original", nprimes)
+		}
+		precomputed := key.Precomputed
+		if precomputed.Dp.Cmp(priv.Precomputed.Dp) != 0 || precomputed.Dq.Cmp(priv.Precomputed.Dq) != 0 || precomputed.Qinv.Cmp(priv.Precomputed.Qinv) != 0 {
+			t.Errorf("%d primes: exported CRT values don't match", nprimes)
+		}
+
+		// Signatures from the exported key should verify with the original, and vice versa
+		hashed := sha256.Sum256([]byte("testing"))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := priv.PublicKey.VerifyPKCS1v15(crypto.SHA256, hashed[:], sig); err != nil {
+			t.Errorf("%d primes: %s", nprimes, err)
+		}
+
+		imported, err := PrivateKeyFromStd(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !imported.Equal(priv) {
+			t.Errorf("%d primes: key doesn't round trip", nprimes)
+		}
+		pub, err := PublicKeyFromStd(priv.PublicKey.ToStd())
+		if err != nil || !pub.Equal(&priv.PublicKey) {
+			t.Errorf("%d primes: public key doesn't round trip: %v", nprimes, err)
+		}
+	}
+}